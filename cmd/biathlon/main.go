@@ -1,9 +1,15 @@
 package main
 
 import (
+	"os"
+
 	"biathlon_events_parser/internal/app"
 )
 
 func main() {
-	app.Run()
+	os.Exit(app.Run(os.Args[1:], os.Stdin, app.Outputs{
+		EventLog:    os.Stdout,
+		Diagnostics: os.Stderr,
+		Report:      os.Stdout,
+	}))
 }
@@ -1,8 +1,13 @@
 package app
 
 import (
-	"log"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
 	"os"
+	"path/filepath"
 	"strings"
 
 	"github.com/joho/godotenv"
@@ -10,49 +15,354 @@ import (
 	"biathlon_events_parser/internal/config"
 	"biathlon_events_parser/internal/event_parser"
 	"biathlon_events_parser/internal/event_process"
+	"biathlon_events_parser/internal/models"
+	"biathlon_events_parser/internal/paths"
 	"biathlon_events_parser/internal/report"
+	"biathlon_events_parser/internal/roster"
 )
 
-func Run() {
-	var envPath strings.Builder
+const (
+	ExitOK      = 0
+	ExitFailure = 1 // an input could not be read or the report could not be written
+	ExitUsage   = 2 // unknown command, bad flag or bad setting
+	ExitInvalid = 3 // the race data is invalid under the chosen modes
+)
+
+var (
+	errUsage   = errors.New("usage error")
+	errInvalid = errors.New("invalid race data")
+
+	// errFlags is returned when the flag package has already printed the
+	// problem together with the command usage.
+	errFlags = errors.New("invalid flags")
+)
+
+const usage = `Usage: biathlon <command> [flags]
+
+Commands:
+  report    process the race and print the final report
+  validate  check the config and events and list every problem
+  log       process the race and print the event log
+  replay    stream the events through the race clock and print the log as it happens
+  startlist write the pursuit start list events from the results of a finished race
 
+Run "biathlon <command> -h" for the flags of a command. Without a command
+biathlon prints the event log followed by the report.
+`
+
+type Outputs struct {
+	EventLog    io.Writer
+	Diagnostics io.Writer
+	Report      io.Writer
+}
+
+func Run(args []string, stdin io.Reader, out Outputs) int {
+	if err := loadEnv(); err != nil {
+		fmt.Fprintf(out.Diagnostics, "biathlon: %v\n", err)
+		return ExitFailure
+	}
+
+	name, rest := "", args
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		name, rest = args[0], args[1:]
+	}
+
+	var err error
+	switch name {
+	case "":
+		err = runReport("biathlon", rest, stdin, out, true)
+	case "report":
+		err = runReport(name, rest, stdin, out, false)
+	case "validate":
+		err = runValidate(rest, stdin, out)
+	case "log":
+		err = runLog(rest, stdin, out)
+	case "replay":
+		err = runReplay(rest, stdin, out)
+	case "startlist":
+		err = runStartList(rest, stdin, out)
+	case "help":
+		fmt.Fprint(out.Report, usage)
+	default:
+		err = fmt.Errorf("%w: unknown command %q\n\n%s", errUsage, name, usage)
+	}
+
+	return exitCode(err, out.Diagnostics)
+}
+
+func exitCode(err error, diag io.Writer) int {
+	switch {
+	case err == nil, errors.Is(err, flag.ErrHelp):
+		return ExitOK
+	case errors.Is(err, errFlags):
+		return ExitUsage
+	}
+
+	fmt.Fprintf(diag, "biathlon: %v\n", err)
+	switch {
+	case errors.Is(err, errUsage):
+		return ExitUsage
+	case errors.Is(err, errInvalid):
+		return ExitInvalid
+	default:
+		return ExitFailure
+	}
+}
+
+func loadEnv() error {
 	homeDir, err := os.Getwd()
 	if err != nil {
-		log.Fatal(err)
+		return err
+	}
+
+	err = godotenv.Load(filepath.Join(homeDir, ".env"))
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("failed to load .env: %w", err)
+	}
+
+	return nil
+}
+
+type settings struct {
+	configPath string
+	eventsPath string
+	rosterPath string
+	unknown    string
+
+	parseMode string
+	maxErrors int
+	strict    bool
+}
+
+func newFlagSet(name string, out Outputs) *flag.FlagSet {
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	flags.SetOutput(out.Diagnostics)
+	return flags
+}
+
+func (s *settings) registerInputs(flags *flag.FlagSet) {
+	flags.StringVar(&s.configPath, "config", "", "race config file, or - for stdin (default $CONFIG_FILE_PATH or configs/config.json)")
+	flags.StringVar(&s.eventsPath, "events", "", "events file, or - for stdin (default $EVENTS_FILE_PATH or events/events)")
+	flags.StringVar(&s.rosterPath, "roster", "", "roster file in .json or .csv format (default $ROSTER_FILE_PATH)")
+	flags.StringVar(&s.unknown, "unknown", envOr("ROSTER_UNKNOWN", "warn"), "competitors missing from the roster: warn or reject")
+}
+
+func (s *settings) registerProcessing(flags *flag.FlagSet) {
+	flags.StringVar(&s.parseMode, "parse-mode", envOr("PARSE_MODE", "lenient"), "malformed event lines: lenient, strict or max-errors")
+	flags.IntVar(&s.maxErrors, "max-errors", 0, "parse errors allowed in max-errors mode (default $PARSE_MAX_ERRORS)")
+	flags.BoolVar(&s.strict, "strict", false, "stop at the first event that breaks the race rules (default $STRICT_MODE)")
+}
+
+func (s *settings) parse(flags *flag.FlagSet, args []string) error {
+	for flagName, key := range map[string]string{"max-errors": "PARSE_MAX_ERRORS", "strict": "STRICT_MODE"} {
+		v := os.Getenv(key)
+		if v == "" || flags.Lookup(flagName) == nil {
+			continue
+		}
+		if err := flags.Set(flagName, v); err != nil {
+			return fmt.Errorf("%w: invalid %s value %q", errUsage, key, v)
+		}
+	}
+
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return err
+		}
+		return fmt.Errorf("%w: %v", errFlags, err)
+	}
+	if flags.NArg() > 0 {
+		return fmt.Errorf("%w: unexpected argument %q", errUsage, flags.Arg(0))
+	}
+
+	diag := flags.Output()
+	s.configPath = inputPath(s.configPath, "CONFIG_FILE_PATH", "configs/config.json", diag)
+	s.eventsPath = inputPath(s.eventsPath, "EVENTS_FILE_PATH", "events/events", diag)
+	s.rosterPath = inputPath(s.rosterPath, "ROSTER_FILE_PATH", "", diag)
+
+	if s.configPath == "-" && s.eventsPath == "-" {
+		return fmt.Errorf("%w: the config and the events cannot both be read from stdin", errUsage)
+	}
+
+	return nil
+}
+
+func inputPath(flagValue, key, fallback string, diag io.Writer) string {
+	if flagValue != "" {
+		return flagValue
+	}
+	return legacyPath(envOr(key, fallback), key, diag)
+}
+
+// legacyPath keeps older .env files working, which wrote paths inside the
+// project with a leading slash. Such a path is read relative to the working
+// directory when only that file exists.
+func legacyPath(path, key string, diag io.Writer) string {
+	if !strings.HasPrefix(path, "/") {
+		return path
+	}
+	if _, err := os.Stat(path); err == nil {
+		return path
 	}
 
-	envPath.WriteString(homeDir)
-	envPath.WriteString("/.env")
+	rel := strings.TrimLeft(path, "/")
+	if _, err := os.Stat(rel); err != nil {
+		return path
+	}
+
+	fmt.Fprintf(diag, "biathlon: %s=%s is deprecated, reading %s; drop the leading slash for paths inside the project\n", key, path, rel)
+	return rel
+}
+
+func envOr(key, fallback string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return fallback
+}
 
-	err = godotenv.Load(envPath.String())
+func openInput(path string, stdin io.Reader) (io.ReadCloser, error) {
+	if path == "-" {
+		return io.NopCloser(stdin), nil
+	}
+
+	resolved, err := paths.Resolve(path)
 	if err != nil {
-		log.Fatal(err)
+		return nil, err
 	}
+	return os.Open(resolved)
+}
 
-	configPath := os.Getenv("CONFIG_FILE_PATH")
-	if configPath == "" {
-		configPath = "/configs/config.json"
+func (s *settings) loadConfig(stdin io.Reader) (*models.Config, error) {
+	file, err := openInput(s.configPath, stdin)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open config file: %w", err)
 	}
+	defer file.Close()
 
-	cfg, err := config.LoadConfig(configPath)
+	cfg, err := config.DecodeConfig(file)
 	if err != nil {
-		log.Fatal(err)
+		return nil, fmt.Errorf("%w: %w", errInvalid, err)
 	}
 
-	eventsPath := os.Getenv("EVENTS_FILE_PATH")
-	if eventsPath == "" {
-		eventsPath = "/events/events"
+	return cfg, nil
+}
+
+func (s *settings) parseOptions(cfg *models.Config, diag io.Writer) (eventparser.Options, error) {
+	opts := eventparser.Options{
+		Mode:        eventparser.ModeLenient,
+		MaxErrors:   s.maxErrors,
+		Diagnostics: diag,
+		Date:        cfg.Date,
 	}
 
-	events, err := eventparser.ParseEvents(eventsPath)
+	switch s.parseMode {
+	case "lenient":
+	case "strict":
+		opts.Mode = eventparser.ModeStrict
+	case "max-errors":
+		opts.Mode = eventparser.ModeMaxErrors
+		if opts.MaxErrors <= 0 {
+			return opts, fmt.Errorf("%w: max-errors mode needs a positive error limit", errUsage)
+		}
+	default:
+		return opts, fmt.Errorf("%w: invalid parse mode %q", errUsage, s.parseMode)
+	}
+
+	return opts, nil
+}
+
+func (s *settings) loadEvents(stdin io.Reader, opts eventparser.Options) ([]*models.Event, []*eventparser.ParseError, error) {
+	file, err := openInput(s.eventsPath, stdin)
 	if err != nil {
-		log.Fatalf("failed to parse events: %v", err)
+		return nil, nil, fmt.Errorf("failed to open event file: %w", err)
 	}
+	defer file.Close()
 
-	comp := eventprocess.ProcessEvents(events, cfg)
+	events, parseErrs, err := eventparser.ReadEvents(file, opts)
+	if err != nil {
+		var perr *eventparser.ParseError
+		if errors.As(err, &perr) || errors.Is(err, eventparser.ErrTooManyErrors) {
+			err = fmt.Errorf("%w: %w", errInvalid, err)
+		}
+		return nil, parseErrs, fmt.Errorf("failed to parse events: %w", err)
+	}
+
+	return events, parseErrs, nil
+}
+
+func (s *settings) processOptions() (eventprocess.Options, error) {
+	opts := eventprocess.Options{Strict: s.strict}
+
+	switch s.unknown {
+	case "warn":
+	case "reject":
+		opts.RejectUnknown = true
+	default:
+		return opts, fmt.Errorf("%w: invalid roster policy %q", errUsage, s.unknown)
+	}
 
-	err = report.MakeReport(comp, cfg)
+	if s.rosterPath == "" {
+		return opts, nil
+	}
+
+	var err error
+	opts.Roster, err = roster.LoadRoster(s.rosterPath)
 	if err != nil {
-		log.Fatalf("failed to create report: %v", err)
+		var pathErr *fs.PathError
+		if !errors.As(err, &pathErr) {
+			err = fmt.Errorf("%w: %w", errInvalid, err)
+		}
+		return opts, err
+	}
+
+	return opts, nil
+}
+
+type reportFunc func(w io.Writer, competitors map[int]*models.Competitor, cfg *models.Config) error
+
+func reportFormat(format string) (reportFunc, error) {
+	switch format {
+	case "text":
+		return report.MakeReport, nil
+	case "json":
+		return report.MakeJSONReport, nil
+	case "html":
+		return report.MakeHTMLReport, nil
+	case "csv", "tsv":
+		comma := delimiter(format)
+		return func(w io.Writer, comp map[int]*models.Competitor, cfg *models.Config) error {
+			return report.WriteResultsCSV(w, comp, cfg, comma)
+		}, nil
+	case "splits-csv", "splits-tsv":
+		comma := delimiter(format)
+		return func(w io.Writer, comp map[int]*models.Competitor, cfg *models.Config) error {
+			return report.WriteSplitsCSV(w, comp, cfg, comma)
+		}, nil
+	default:
+		return nil, fmt.Errorf("%w: unknown report format %q", errUsage, format)
+	}
+}
+
+func writeReport(w io.Writer, output string, makeReport reportFunc, competitors map[int]*models.Competitor, cfg *models.Config) error {
+	if output != "" {
+		file, err := os.Create(output)
+		if err != nil {
+			return fmt.Errorf("failed to create report file: %w", err)
+		}
+		defer file.Close()
+		w = file
+	}
+
+	if err := makeReport(w, competitors, cfg); err != nil {
+		return fmt.Errorf("failed to create report: %w", err)
+	}
+
+	return nil
+}
+
+func delimiter(format string) rune {
+	if strings.HasSuffix(format, "tsv") {
+		return '\t'
 	}
+	return ','
 }
@@ -0,0 +1,280 @@
+package app
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testConfig = `{
+	"laps": 1,
+	"lapLen": 1000,
+	"penaltyLen": 150,
+	"firingLines": 1,
+	"start": "10:00:00.000",
+	"startDelta": "00:01:00"
+}`
+
+const testEvents = `[09:50:00.000] 1 1
+[09:51:00.000] 2 1 10:00:00.000
+[09:59:00.000] 3 1
+[10:00:01.000] 4 1
+[10:01:00.000] 5 1 1
+[10:01:05.000] 6 1 1
+[10:01:10.000] 7 1
+[10:05:00.000] 10 1
+`
+
+type testRun struct {
+	log, diag, report bytes.Buffer
+}
+
+func writeInputs(t *testing.T, events string) (configPath, eventsPath string) {
+	t.Helper()
+
+	for _, key := range []string{"CONFIG_FILE_PATH", "EVENTS_FILE_PATH", "ROSTER_FILE_PATH", "ROSTER_UNKNOWN",
+		"PARSE_MODE", "PARSE_MAX_ERRORS", "STRICT_MODE"} {
+		t.Setenv(key, "")
+	}
+
+	dir := t.TempDir()
+	configPath = filepath.Join(dir, "config.json")
+	eventsPath = filepath.Join(dir, "events")
+	if err := os.WriteFile(configPath, []byte(testConfig), 0644); err != nil {
+		t.Fatalf("Failed to write config file: %v", err)
+	}
+	if err := os.WriteFile(eventsPath, []byte(events), 0644); err != nil {
+		t.Fatalf("Failed to write events file: %v", err)
+	}
+	return configPath, eventsPath
+}
+
+func run(stdin string, args ...string) (int, *testRun) {
+	var r testRun
+	code := Run(args, strings.NewReader(stdin), Outputs{EventLog: &r.log, Diagnostics: &r.diag, Report: &r.report})
+	return code, &r
+}
+
+func TestRunReport(t *testing.T) {
+	configPath, eventsPath := writeInputs(t, testEvents)
+
+	code, r := run("", "report", "--config", configPath, "--events", eventsPath, "--format", "csv")
+	if code != ExitOK {
+		t.Fatalf("Run() = %d, want %d; diagnostics: %s", code, ExitOK, r.diag.String())
+	}
+	if r.log.Len() != 0 {
+		t.Errorf("report should not print the event log, got %q", r.log.String())
+	}
+	if !strings.Contains(r.report.String(), "1,1,,,,,Finished,05:00.000,") {
+		t.Errorf("report output = %q, want the finisher's row", r.report.String())
+	}
+
+	code, r = run("", "-config", configPath, "-events", eventsPath)
+	if code != ExitOK {
+		t.Fatalf("Run() without a command = %d, want %d", code, ExitOK)
+	}
+	if !strings.Contains(r.log.String(), "[10:05:00.000] Competitor(1) has finished") {
+		t.Errorf("Run() without a command should print the event log, got %q", r.log.String())
+	}
+	if !strings.HasPrefix(r.report.String(), "  1 [Finished]") {
+		t.Errorf("Run() without a command should print the text report, got %q", r.report.String())
+	}
+}
+
+func TestRunReportDiagnostics(t *testing.T) {
+	configPath, eventsPath := writeInputs(t, testEvents+"[10:06:00.000] 4 1\n")
+
+	code, r := run("", "report", "--config", configPath, "--events", eventsPath)
+	if code != ExitOK {
+		t.Fatalf("Run() = %d, want %d; diagnostics: %s", code, ExitOK, r.diag.String())
+	}
+	want := "Rejected event at line 9: [10:06:00.000] Competitor(1): event 4 is not allowed while competitor is finished\n"
+	if r.diag.String() != want {
+		t.Errorf("diagnostics = %q, want %q", r.diag.String(), want)
+	}
+	if r.log.Len() != 0 {
+		t.Errorf("report should not print the event log, got %q", r.log.String())
+	}
+}
+
+func TestRunLegacyEnvPaths(t *testing.T) {
+	writeInputs(t, testEvents)
+
+	dir := t.TempDir()
+	t.Chdir(dir)
+	for name, content := range map[string]string{"legacy-inputs/config.json": testConfig, "legacy-inputs/events": testEvents} {
+		if err := os.MkdirAll(filepath.Dir(name), 0755); err != nil {
+			t.Fatalf("Failed to create input dir: %v", err)
+		}
+		if err := os.WriteFile(name, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+	t.Setenv("CONFIG_FILE_PATH", "/legacy-inputs/config.json")
+	t.Setenv("EVENTS_FILE_PATH", "/legacy-inputs/events")
+
+	code, r := run("", "report")
+	if code != ExitOK {
+		t.Fatalf("Run() = %d, want %d; diagnostics: %s", code, ExitOK, r.diag.String())
+	}
+	want := "biathlon: CONFIG_FILE_PATH=/legacy-inputs/config.json is deprecated, reading legacy-inputs/config.json; " +
+		"drop the leading slash for paths inside the project\n"
+	if !strings.HasPrefix(r.diag.String(), want) {
+		t.Errorf("diagnostics = %q, want the deprecation warning %q", r.diag.String(), want)
+	}
+	if !strings.HasPrefix(r.report.String(), "  1 [Finished]") {
+		t.Errorf("report = %q, want the finisher", r.report.String())
+	}
+
+	t.Setenv("CONFIG_FILE_PATH", "/legacy-inputs/missing.json")
+	if code, _ := run("", "report"); code != ExitFailure {
+		t.Errorf("Run() with a missing legacy path = %d, want %d", code, ExitFailure)
+	}
+}
+
+func TestRunStdin(t *testing.T) {
+	configPath, _ := writeInputs(t, testEvents)
+
+	code, r := run(testEvents, "log", "--config", configPath, "--events", "-")
+	if code != ExitOK {
+		t.Fatalf("Run() = %d, want %d; diagnostics: %s", code, ExitOK, r.diag.String())
+	}
+	if !strings.HasPrefix(r.log.String(), "[09:50:00.000] Competitor(1) has registered\n") {
+		t.Errorf("log output = %q, want the events read from stdin", r.log.String())
+	}
+	if r.report.Len() != 0 {
+		t.Errorf("log should not print a report, got %q", r.report.String())
+	}
+}
+
+func TestRunValidate(t *testing.T) {
+	configPath, eventsPath := writeInputs(t, testEvents)
+
+	code, r := run("", "validate", "--config", configPath, "--events", eventsPath)
+	if code != ExitOK || r.report.String() != "no problems found\n" {
+		t.Errorf("validate = %d with %q, want %d and no problems", code, r.report.String(), ExitOK)
+	}
+
+	_, eventsPath = writeInputs(t, testEvents+"[10:06:00.000] x 1\n[10:07:00.000] 4 1\n")
+	code, r = run("", "validate", "--config", configPath, "--events", eventsPath)
+	if code != ExitInvalid {
+		t.Errorf("validate = %d, want %d", code, ExitInvalid)
+	}
+
+	want := "line 9, column 16: invalid event ID \"x\": \"[10:06:00.000] x 1\"\n" +
+		"line 10: [10:07:00.000] Competitor(1): event 4 is not allowed while competitor is finished\n"
+	if r.report.String() != want {
+		t.Errorf("validate output = %q, want %q", r.report.String(), want)
+	}
+	if !strings.Contains(r.diag.String(), "2 problems found") {
+		t.Errorf("diagnostics = %q, want the problem count", r.diag.String())
+	}
+}
+
+func TestRunExitCodes(t *testing.T) {
+	configPath, eventsPath := writeInputs(t, testEvents+"[10:06:00.000] x 1\n")
+
+	tests := []struct {
+		name string
+		args []string
+		want int
+	}{
+		{"unknown command", []string{"race"}, ExitUsage},
+		{"unknown flag", []string{"report", "--nope"}, ExitUsage},
+		{"unknown format", []string{"report", "--config", configPath, "--events", eventsPath, "--format", "pdf"}, ExitUsage},
+		{"both from stdin", []string{"report", "--config", "-", "--events", "-"}, ExitUsage},
+		{"missing file", []string{"report", "--config", configPath, "--events", eventsPath + ".missing"}, ExitFailure},
+		{"strict parsing", []string{"report", "--config", configPath, "--events", eventsPath, "--parse-mode", "strict"}, ExitInvalid},
+		{"help", []string{"log", "-h"}, ExitOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if code, r := run("", tt.args...); code != tt.want {
+				t.Errorf("Run(%v) = %d, want %d; diagnostics: %s", tt.args, code, tt.want, r.diag.String())
+			}
+		})
+	}
+}
+
+func TestRunReplay(t *testing.T) {
+	configPath, eventsPath := writeInputs(t, testEvents)
+
+	var waits []time.Duration
+	sleep = func(d time.Duration) { waits = append(waits, d) }
+	defer func() { sleep = time.Sleep }()
+
+	code, r := run("", "replay", "--config", configPath, "--events", eventsPath, "--speed", "60")
+	if code != ExitOK {
+		t.Fatalf("Run() = %d, want %d; diagnostics: %s", code, ExitOK, r.diag.String())
+	}
+
+	if len(waits) != 7 || waits[0] != time.Second {
+		t.Errorf("replay waits = %v, want 7 waits starting with 1s", waits)
+	}
+	if !strings.Contains(r.log.String(), "Competitor(1) has finished") {
+		t.Errorf("replay log = %q, want the finish", r.log.String())
+	}
+	if !strings.HasPrefix(r.report.String(), "  1 [Finished]") {
+		t.Errorf("replay report = %q, want the final standings", r.report.String())
+	}
+}
+
+func TestRunValidateConfig(t *testing.T) {
+	configPath, eventsPath := writeInputs(t, testEvents+"[10:06:00.000] x 1\n")
+	badConfig := strings.Replace(testConfig, `"laps": 1,`, `"laps": 0, "lap": 1,`, 1)
+	if err := os.WriteFile(configPath, []byte(badConfig), 0644); err != nil {
+		t.Fatalf("Failed to write config file: %v", err)
+	}
+
+	code, r := run("", "validate", "--config", configPath, "--events", eventsPath)
+	if code != ExitInvalid {
+		t.Errorf("validate = %d, want %d", code, ExitInvalid)
+	}
+
+	want := "config: laps: must be positive, got 0\n" +
+		"config: lap: unknown field\n" +
+		"line 9, column 16: invalid event ID \"x\": \"[10:06:00.000] x 1\"\n"
+	if r.report.String() != want {
+		t.Errorf("validate output = %q, want %q", r.report.String(), want)
+	}
+}
+
+func TestRunStartList(t *testing.T) {
+	configPath, eventsPath := writeInputs(t, testEvents+`[09:50:30.000] 1 2
+[09:51:30.000] 2 2 10:00:30.000
+[09:59:30.000] 3 2
+[10:00:30.000] 4 2
+[10:01:40.000] 5 2 1
+[10:01:50.000] 7 2
+[10:02:00.000] 8 2
+[10:03:00.000] 9 2
+[10:06:00.000] 10 2
+`)
+
+	racePath := filepath.Join(filepath.Dir(configPath), "pursuit.json")
+	race := strings.Replace(testConfig, `"start": "10:00:00.000"`, `"start": "14:00:00.000"`, 1)
+	if err := os.WriteFile(racePath, []byte(race), 0644); err != nil {
+		t.Fatalf("Failed to write race config: %v", err)
+	}
+
+	code, r := run("", "startlist", "--config", configPath, "--events", eventsPath, "--race", racePath)
+	if code != ExitOK {
+		t.Fatalf("Run() = %d, want %d; diagnostics: %s", code, ExitOK, r.diag.String())
+	}
+
+	want := "[13:30:00.000] 1 1\n" +
+		"[13:30:00.000] 1 2\n" +
+		"[13:30:00.000] 2 1 14:00:00.000\n" +
+		"[13:30:00.000] 2 2 14:00:30.000\n"
+	if r.report.String() != want {
+		t.Errorf("startlist output =\n%s\nwant\n%s", r.report.String(), want)
+	}
+
+	if code, _ := run("", "startlist", "--config", configPath, "--events", eventsPath); code != ExitUsage {
+		t.Errorf("startlist without -race = %d, want %d", code, ExitUsage)
+	}
+}
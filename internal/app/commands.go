@@ -0,0 +1,277 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"biathlon_events_parser/internal/config"
+	"biathlon_events_parser/internal/event_parser"
+	"biathlon_events_parser/internal/event_process"
+	"biathlon_events_parser/internal/models"
+	"biathlon_events_parser/internal/startlist"
+)
+
+var sleep = time.Sleep
+
+const reportFormats = "report format: text, json, html, csv, tsv, splits-csv or splits-tsv"
+
+func runReport(name string, args []string, stdin io.Reader, out Outputs, withLog bool) error {
+	flags := newFlagSet(name, out)
+	var s settings
+	s.registerInputs(flags)
+	s.registerProcessing(flags)
+	format := flags.String("format", "text", reportFormats)
+	output := flags.String("output", "", "write the report to this file instead of stdout")
+	if err := s.parse(flags, args); err != nil {
+		return err
+	}
+
+	makeReport, err := reportFormat(*format)
+	if err != nil {
+		return err
+	}
+
+	eventLog := io.Discard
+	if withLog {
+		eventLog = out.EventLog
+	}
+
+	competitors, _, cfg, err := s.process(stdin, eventLog, out.Diagnostics)
+	if err != nil {
+		return err
+	}
+	return writeReport(out.Report, *output, makeReport, competitors, cfg)
+}
+
+func runLog(args []string, stdin io.Reader, out Outputs) error {
+	flags := newFlagSet("log", out)
+	var s settings
+	s.registerInputs(flags)
+	s.registerProcessing(flags)
+	if err := s.parse(flags, args); err != nil {
+		return err
+	}
+
+	_, _, _, err := s.process(stdin, out.EventLog, out.Diagnostics)
+	return err
+}
+
+func runValidate(args []string, stdin io.Reader, out Outputs) error {
+	flags := newFlagSet("validate", out)
+	var s settings
+	s.registerInputs(flags)
+	if err := s.parse(flags, args); err != nil {
+		return err
+	}
+
+	problems := 0
+
+	cfg, err := s.loadConfig(stdin)
+	var verr *config.ValidationError
+	switch {
+	case errors.As(err, &verr):
+		for _, p := range verr.Problems {
+			fmt.Fprintf(out.Report, "config: %v\n", p)
+		}
+		problems += len(verr.Problems)
+	case err != nil:
+		return err
+	}
+
+	processOpts, err := s.processOptions()
+	if err != nil {
+		return err
+	}
+
+	parseOpts := eventparser.Options{Mode: eventparser.ModeLenient}
+	if cfg != nil {
+		parseOpts.Date = cfg.Date
+	}
+
+	events, parseErrs, err := s.loadEvents(stdin, parseOpts)
+	if err != nil {
+		return err
+	}
+	for _, perr := range parseErrs {
+		fmt.Fprintln(out.Report, perr)
+	}
+	problems += len(parseErrs)
+
+	if cfg != nil {
+		_, violations, err := eventprocess.ProcessEvents(io.Discard, events, cfg, processOpts)
+		if err != nil {
+			return err
+		}
+		for _, v := range violations {
+			fmt.Fprintln(out.Report, v)
+		}
+		problems += len(violations)
+	}
+
+	if problems > 0 {
+		return fmt.Errorf("%w: %d problems found", errInvalid, problems)
+	}
+
+	fmt.Fprintln(out.Report, "no problems found")
+	return nil
+}
+
+func runReplay(args []string, stdin io.Reader, out Outputs) error {
+	flags := newFlagSet("replay", out)
+	var s settings
+	s.registerInputs(flags)
+	s.registerProcessing(flags)
+	format := flags.String("format", "text", reportFormats)
+	speed := flags.Float64("speed", 0, "race seconds per wall-clock second, 0 replays without waiting")
+	if err := s.parse(flags, args); err != nil {
+		return err
+	}
+	if *speed < 0 {
+		return fmt.Errorf("%w: speed must not be negative", errUsage)
+	}
+
+	makeReport, err := reportFormat(*format)
+	if err != nil {
+		return err
+	}
+
+	cfg, err := s.loadConfig(stdin)
+	if err != nil {
+		return err
+	}
+
+	parseOpts, err := s.parseOptions(cfg, out.Diagnostics)
+	if err != nil {
+		return err
+	}
+
+	processOpts, err := s.processOptions()
+	if err != nil {
+		return err
+	}
+
+	file, err := openInput(s.eventsPath, stdin)
+	if err != nil {
+		return fmt.Errorf("failed to open event file: %w", err)
+	}
+	defer file.Close()
+
+	p := eventprocess.NewProcessor(cfg, out.EventLog)
+	p.SetDiagnostics(out.Diagnostics)
+	if processOpts.Roster != nil {
+		p.SetRoster(processOpts.Roster, processOpts.RejectUnknown)
+	}
+
+	dec := eventparser.NewDecoder(file, parseOpts)
+	var last *models.Event
+	for {
+		ev, err := dec.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			var perr *eventparser.ParseError
+			if errors.As(err, &perr) || errors.Is(err, eventparser.ErrTooManyErrors) {
+				err = fmt.Errorf("%w: %w", errInvalid, err)
+			}
+			return fmt.Errorf("failed to parse events: %w", err)
+		}
+
+		if last == nil || ev.Time.After(last.Time) {
+			if last != nil && *speed > 0 {
+				sleep(time.Duration(float64(ev.Time.Sub(last.Time)) / *speed))
+			}
+			last = ev
+		}
+
+		if err := p.Apply(ev); err != nil && s.strict {
+			return fmt.Errorf("%w: %w", errInvalid, err)
+		}
+	}
+
+	return writeReport(out.Report, "", makeReport, p.Finalize(), cfg)
+}
+
+func runStartList(args []string, stdin io.Reader, out Outputs) error {
+	flags := newFlagSet("startlist", out)
+	var s settings
+	s.registerInputs(flags)
+	s.registerProcessing(flags)
+	racePath := flags.String("race", "", "config of the pursuit race whose start list is written")
+	top := flags.Int("top", 60, "number of finishers that qualify, 0 takes all of them")
+	lead := flags.Duration("lead", 30*time.Minute, "how long before the start competitors are registered and drawn")
+	output := flags.String("output", "", "write the events to this file instead of stdout")
+	if err := s.parse(flags, args); err != nil {
+		return err
+	}
+	if *racePath == "" {
+		return fmt.Errorf("%w: -race is required", errUsage)
+	}
+	if *racePath == "-" && (s.configPath == "-" || s.eventsPath == "-") {
+		return fmt.Errorf("%w: only one input can be read from stdin", errUsage)
+	}
+	if *top < 0 || *lead < 0 {
+		return fmt.Errorf("%w: -top and -lead must not be negative", errUsage)
+	}
+
+	race := settings{configPath: *racePath}
+	raceCfg, err := race.loadConfig(stdin)
+	if err != nil {
+		return err
+	}
+
+	competitors, _, cfg, err := s.process(stdin, io.Discard, out.Diagnostics)
+	if err != nil {
+		return err
+	}
+
+	entries := startlist.Pursuit(competitors, cfg, raceCfg.StartTime, *top)
+	if len(entries) == 0 {
+		return fmt.Errorf("%w: the race has no finishers", errInvalid)
+	}
+
+	w := out.Report
+	if *output != "" {
+		file, err := os.Create(*output)
+		if err != nil {
+			return fmt.Errorf("failed to create start list file: %w", err)
+		}
+		defer file.Close()
+		w = file
+	}
+
+	return startlist.Write(w, entries, raceCfg.StartTime.Add(-*lead))
+}
+
+func (s *settings) process(stdin io.Reader, eventLog, diag io.Writer) (map[int]*models.Competitor, []*eventprocess.Violation, *models.Config, error) {
+	cfg, err := s.loadConfig(stdin)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	parseOpts, err := s.parseOptions(cfg, diag)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	processOpts, err := s.processOptions()
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	events, _, err := s.loadEvents(stdin, parseOpts)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	processOpts.Diagnostics = diag
+	competitors, violations, err := eventprocess.ProcessEvents(eventLog, events, cfg, processOpts)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("%w: %w", errInvalid, err)
+	}
+
+	return competitors, violations, cfg, nil
+}
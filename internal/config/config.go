@@ -3,65 +3,204 @@ package config
 import (
 	"encoding/json"
 	"fmt"
+	"io"
+	"io/fs"
 	"os"
+	"sort"
+	"strconv"
 	"strings"
 	"time"
 
 	"biathlon_events_parser/internal/models"
+	"biathlon_events_parser/internal/paths"
+	"biathlon_events_parser/internal/rules"
 )
 
 func LoadConfig(configPath string) (*models.Config, error) {
-	homeDir, err := os.Getwd()
+	path, err := paths.Resolve(configPath)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("failed to open config file: %w", err)
 	}
 
-	var path strings.Builder
-	path.WriteString(homeDir)
-	path.WriteString(configPath)
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open config file: %w", err)
+	}
+	defer file.Close()
+
+	return DecodeConfig(file)
+}
 
-	file, err := os.Open(path.String())
+func LoadConfigFS(fsys fs.FS, name string) (*models.Config, error) {
+	file, err := fsys.Open(name)
 	if err != nil {
 		return nil, fmt.Errorf("failed to open config file: %w", err)
 	}
 	defer file.Close()
 
-	dec := json.NewDecoder(file)
-	temp := struct {
-		Laps        int    `json:"laps"`
-		LapLen      int    `json:"lapLen"`
-		PenaltyLen  int    `json:"penaltyLen"`
-		FiringLines int    `json:"firingLines"`
-		Start       string `json:"start"`
-		StartDelta  string `json:"startDelta"`
-	}{}
+	return DecodeConfig(file)
+}
 
-	if err := dec.Decode(&temp); err != nil {
+func DecodeConfig(r io.Reader) (*models.Config, error) {
+	var raw map[string]json.RawMessage
+	if err := json.NewDecoder(r).Decode(&raw); err != nil {
 		return nil, fmt.Errorf("failed to decode config: %w", err)
 	}
 
-	startTime, err := time.Parse("15:04:05.000", temp.Start)
-	if err != nil {
-		return nil, fmt.Errorf("invalid time format for Start in config: %w", err)
-	}
+	temp := struct {
+		Format      string
+		Laps        int
+		LapLen      int
+		PenaltyLen  int
+		FiringLines int
+		Date        string
+		Start       string
+		StartDelta  string
+		MissPenalty string
+	}{}
 
-	delta, err := time.Parse("15:04:05", temp.StartDelta)
-	if err != nil {
-		return nil, fmt.Errorf("invalid format for StartDelta: %s", temp.StartDelta)
+	fields := []struct {
+		name   string
+		target any
+		kind   string
+	}{
+		{"format", &temp.Format, "a string"},
+		{"laps", &temp.Laps, "an integer"},
+		{"lapLen", &temp.LapLen, "an integer"},
+		{"penaltyLen", &temp.PenaltyLen, "an integer"},
+		{"firingLines", &temp.FiringLines, "an integer"},
+		{"date", &temp.Date, "a string"},
+		{"start", &temp.Start, "a string"},
+		{"startDelta", &temp.StartDelta, "a string"},
+		{"missPenalty", &temp.MissPenalty, "a string"},
 	}
 
-	startDelta := time.Duration(delta.Hour())*time.Hour +
-		time.Duration(delta.Minute())*time.Minute +
-		time.Duration(delta.Second())*time.Second
+	errs := &ValidationError{}
+	present := make(map[string]bool, len(fields))
+	for _, f := range fields {
+		value, ok := raw[f.name]
+		if !ok {
+			// penaltyLen is only required for formats with penalty loops,
+			// which is checked once the format is known
+			switch f.name {
+			case "format", "date", "missPenalty", "penaltyLen":
+			default:
+				errs.add(f.name, "is required")
+			}
+			continue
+		}
+		delete(raw, f.name)
+
+		if err := json.Unmarshal(value, f.target); err != nil {
+			errs.add(f.name, "must be %s, got %s", f.kind, value)
+			continue
+		}
+		present[f.name] = true
+	}
 
 	cfg := models.Config{
+		Format:      models.RaceFormat(temp.Format),
 		Laps:        temp.Laps,
 		LapLen:      temp.LapLen,
 		PenaltyLen:  temp.PenaltyLen,
 		FiringLines: temp.FiringLines,
-		StartTime:   startTime,
-		StartDelta:  startDelta,
+	}
+
+	if !present["penaltyLen"] && !errs.has("penaltyLen") && rules.For(&cfg).PenaltyLoops() {
+		errs.add("penaltyLen", "is required")
+	}
+
+	if present["date"] {
+		date, err := time.Parse(time.DateOnly, temp.Date)
+		if err != nil {
+			errs.add("date", "must be a date like 2024-03-01, got %q", temp.Date)
+		}
+		cfg.Date = date
+	}
+
+	if present["start"] {
+		startTime, err := parseTimeOfDay(temp.Start)
+		if err != nil {
+			errs.add("start", "must be a time of day like 10:00:00.000, got %q", temp.Start)
+		}
+		cfg.StartTime = startTime
+		if !cfg.Date.IsZero() {
+			y, m, d := cfg.Date.Date()
+			cfg.StartTime = time.Date(y, m, d, startTime.Hour(), startTime.Minute(), startTime.Second(),
+				startTime.Nanosecond(), time.UTC)
+		}
+	}
+
+	if present["startDelta"] {
+		delta, err := parseDuration(temp.StartDelta)
+		if err != nil {
+			errs.add("startDelta", "must be a duration like 00:01:30 or 1m30s, got %q", temp.StartDelta)
+		}
+		cfg.StartDelta = delta
+	}
+
+	if present["missPenalty"] {
+		penalty, err := parseDuration(temp.MissPenalty)
+		if err != nil {
+			errs.add("missPenalty", "must be a duration like 00:01:00 or 1m, got %q", temp.MissPenalty)
+		}
+		cfg.MissPenalty = penalty
+	} else if cfg.Format == models.FormatIndividual {
+		cfg.MissPenalty = rules.DefaultMissPenalty
+	}
+
+	validate(&cfg, errs)
+
+	unknown := make([]string, 0, len(raw))
+	for name := range raw {
+		unknown = append(unknown, name)
+	}
+	sort.Strings(unknown)
+	for _, name := range unknown {
+		errs.add(name, "unknown field")
+	}
+
+	if len(errs.Problems) > 0 {
+		return nil, errs
 	}
 
 	return &cfg, nil
 }
+
+func parseTimeOfDay(s string) (time.Time, error) {
+	t, err := time.Parse("15:04:05.000", s)
+	if err != nil {
+		t, err = time.Parse(time.TimeOnly, s)
+	}
+	return t, err
+}
+
+// parseDuration accepts Go duration strings like "1m30s" as well as the
+// clock format "HH:MM:SS[.sss]", where the hours may exceed 24.
+func parseDuration(s string) (time.Duration, error) {
+	if d, err := time.ParseDuration(s); err == nil {
+		return d, nil
+	}
+
+	parts := strings.Split(s, ":")
+	if len(parts) != 3 {
+		return 0, fmt.Errorf("invalid duration %q", s)
+	}
+
+	hours, err := strconv.Atoi(parts[0])
+	if err != nil || strings.Trim(parts[0], "0123456789") != "" {
+		return 0, fmt.Errorf("invalid hours in duration %q", s)
+	}
+
+	minutes, err := strconv.Atoi(parts[1])
+	if err != nil || strings.Trim(parts[1], "0123456789") != "" || minutes > 59 || len(parts[1]) != 2 {
+		return 0, fmt.Errorf("invalid minutes in duration %q", s)
+	}
+
+	seconds, err := time.ParseDuration(parts[2] + "s")
+	if err != nil || seconds < 0 || seconds >= time.Minute || len(parts[2]) < 2 || parts[2][0] == '+' {
+		return 0, fmt.Errorf("invalid seconds in duration %q", s)
+	}
+
+	return time.Duration(hours)*time.Hour + time.Duration(minutes)*time.Minute + seconds, nil
+}
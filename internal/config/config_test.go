@@ -1,9 +1,13 @@
 package config
 
 import (
+	"errors"
 	"os"
 	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
+	"testing/fstest"
 	"time"
 )
 
@@ -26,7 +30,7 @@ func TestLoadConfig(t *testing.T) {
 		"laps": 3,
 		"lapLen": 1000,
 		"penaltyLen": 150,
-		"firingLines": 5,
+		"firingLines": 2,
 		"start": "10:00:00.000",
 		"startDelta": "00:30:00"
 	}`
@@ -36,7 +40,7 @@ func TestLoadConfig(t *testing.T) {
 		t.Fatalf("Failed to write config file: %v", err)
 	}
 
-	cfg, err := LoadConfig("/configs/config.json")
+	cfg, err := LoadConfig("configs/config.json")
 	if err != nil {
 		t.Fatalf("LoadConfig() error = %v", err)
 	}
@@ -53,8 +57,8 @@ func TestLoadConfig(t *testing.T) {
 		t.Errorf("cfg.PenaltyLen = %d, want 150", cfg.PenaltyLen)
 	}
 
-	if cfg.FiringLines != 5 {
-		t.Errorf("cfg.FiringLines = %d, want 5", cfg.FiringLines)
+	if cfg.FiringLines != 2 {
+		t.Errorf("cfg.FiringLines = %d, want 2", cfg.FiringLines)
 	}
 
 	expectedStartTime, _ := time.Parse("15:04:05.000", "10:00:00.000")
@@ -69,7 +73,7 @@ func TestLoadConfig(t *testing.T) {
 }
 
 func TestLoadConfigErrors(t *testing.T) {
-	_, err := LoadConfig("/non_existent_config.json")
+	_, err := LoadConfig("non_existent_config.json")
 	if err == nil {
 		t.Error("LoadConfig() should return error for non-existent file")
 	}
@@ -88,7 +92,7 @@ func TestLoadConfigErrors(t *testing.T) {
 		t.Fatalf("Failed to write invalid config file: %v", err)
 	}
 
-	_, err = LoadConfig("/configs/invalid.json")
+	_, err = LoadConfig("configs/invalid.json")
 	if err == nil {
 		t.Error("LoadConfig() should return error for invalid JSON")
 	}
@@ -107,8 +111,200 @@ func TestLoadConfigErrors(t *testing.T) {
 		t.Fatalf("Failed to write invalid time config file: %v", err)
 	}
 
-	_, err = LoadConfig("/configs/invalid_time.json")
+	_, err = LoadConfig(invalidTimeFile)
 	if err == nil {
 		t.Error("LoadConfig() should return error for invalid time format")
 	}
 }
+
+func TestLoadConfigFS(t *testing.T) {
+	fsys := fstest.MapFS{
+		"race/config.json": {Data: []byte(`{
+			"laps": 2,
+			"lapLen": 3500,
+			"penaltyLen": 150,
+			"firingLines": 2,
+			"start": "10:00:00.000",
+			"startDelta": "00:01:30"
+		}`)},
+	}
+
+	cfg, err := LoadConfigFS(fsys, "race/config.json")
+	if err != nil {
+		t.Fatalf("LoadConfigFS() error = %v", err)
+	}
+	if cfg.Laps != 2 || cfg.LapLen != 3500 || cfg.StartDelta != 90*time.Second {
+		t.Errorf("LoadConfigFS() = %+v, want the race/config.json values", cfg)
+	}
+
+	if _, err := LoadConfigFS(fsys, "missing.json"); err == nil {
+		t.Error("LoadConfigFS() should return error for non-existent file")
+	}
+}
+
+func TestDecodeConfigValidation(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    []string
+	}{
+		{
+			name: "non-positive values",
+			content: `{"laps": 0, "lapLen": -1, "penaltyLen": 150, "firingLines": 1,
+				"start": "10:00:00.000", "startDelta": "00:01:30"}`,
+			want: []string{"laps: must be positive, got 0", "lapLen: must be positive, got -1"},
+		},
+		{
+			name: "firing lines and laps",
+			content: `{"laps": 2, "lapLen": 1000, "penaltyLen": 150, "firingLines": 3,
+				"start": "10:00:00.000", "startDelta": "00:01:30"}`,
+			want: []string{"firingLines: 3 firing lines do not fit into 2 laps"},
+		},
+		{
+			name: "unknown format",
+			content: `{"format": "single-mixed-relay", "laps": 2, "lapLen": 1000, "penaltyLen": 150, "firingLines": 2,
+				"start": "10:00:00.000", "startDelta": "00:01:30"}`,
+			want: []string{`format: unknown race format "single-mixed-relay", want sprint, individual, pursuit, mass-start or relay`},
+		},
+		{
+			name: "stages of the format",
+			content: `{"format": "sprint", "laps": 2, "lapLen": 1000, "penaltyLen": 150, "firingLines": 2,
+				"start": "10:00:00.000", "startDelta": "00:01:30"}`,
+			want: []string{"laps: a sprint race has 3 laps, got 2"},
+		},
+		{
+			name: "miss penalty outside an individual race",
+			content: `{"format": "sprint", "laps": 3, "lapLen": 1000, "penaltyLen": 150, "firingLines": 2,
+				"start": "10:00:00.000", "startDelta": "00:01:30", "missPenalty": "1m"}`,
+			want: []string{"missPenalty: only applies to individual races, misses are served as penalty loops"},
+		},
+		{
+			name: "individual race without penalty loops",
+			content: `{"format": "individual", "laps": 5, "lapLen": 4000, "firingLines": 4,
+				"start": "10:00:00.000", "startDelta": "00:01:30"}`,
+		},
+		{
+			name: "individual race with a zero penalty loop length",
+			content: `{"format": "individual", "laps": 5, "lapLen": 4000, "penaltyLen": 0, "firingLines": 4,
+				"start": "10:00:00.000", "startDelta": "00:01:30"}`,
+		},
+		{
+			name: "missing penalty loop length",
+			content: `{"format": "sprint", "laps": 3, "lapLen": 1000, "firingLines": 2,
+				"start": "10:00:00.000", "startDelta": "00:01:30"}`,
+			want: []string{"penaltyLen: is required"},
+		},
+		{
+			name: "penalty loop length of a race with penalty loops",
+			content: `{"format": "pursuit", "laps": 5, "lapLen": 1000, "penaltyLen": 0, "firingLines": 4,
+				"start": "10:00:00.000", "startDelta": "00:01:30"}`,
+			want: []string{"penaltyLen: must be positive, got 0"},
+		},
+		{
+			name: "unknown, missing and mistyped fields",
+			content: `{"laps": "two", "lapLen": 1000, "penaltyLen": 150, "firingLine": 1, "lap": 2,
+				"start": "10:00", "startDelta": "00:01:30"}`,
+			want: []string{
+				`laps: must be an integer, got "two"`,
+				"firingLines: is required",
+				`start: must be a time of day like 10:00:00.000, got "10:00"`,
+				"firingLine: unknown field",
+				"lap: unknown field",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := DecodeConfig(strings.NewReader(tt.content))
+			if len(tt.want) == 0 {
+				if err != nil {
+					t.Errorf("DecodeConfig() error = %v, want a valid config", err)
+				}
+				return
+			}
+
+			var verr *ValidationError
+			if !errors.As(err, &verr) {
+				t.Fatalf("DecodeConfig() error = %v, want a ValidationError", err)
+			}
+
+			got := make([]string, 0, len(verr.Problems))
+			for _, p := range verr.Problems {
+				got = append(got, p.Error())
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("DecodeConfig() problems =\n%q\nwant\n%q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecodeConfigTimes(t *testing.T) {
+	tests := []struct {
+		startDelta string
+		want       time.Duration
+	}{
+		{"00:01:30", 90 * time.Second},
+		{"1m30s", 90 * time.Second},
+		{"1.5s", 1500 * time.Millisecond},
+		{"00:00:01.250", 1250 * time.Millisecond},
+		{"26:00:00", 26 * time.Hour},
+		{"36h", 36 * time.Hour},
+	}
+
+	for _, tt := range tests {
+		content := `{"laps": 2, "lapLen": 1000, "penaltyLen": 150, "firingLines": 2,
+			"date": "2024-03-01", "start": "23:30:00.000", "startDelta": "` + tt.startDelta + `"}`
+
+		cfg, err := DecodeConfig(strings.NewReader(content))
+		if err != nil {
+			t.Errorf("DecodeConfig() with startDelta %q error = %v", tt.startDelta, err)
+			continue
+		}
+		if cfg.StartDelta != tt.want {
+			t.Errorf("startDelta %q = %v, want %v", tt.startDelta, cfg.StartDelta, tt.want)
+		}
+
+		wantStart := time.Date(2024, 3, 1, 23, 30, 0, 0, time.UTC)
+		if !cfg.StartTime.Equal(wantStart) {
+			t.Errorf("cfg.StartTime = %v, want %v", cfg.StartTime, wantStart)
+		}
+	}
+
+	for _, startDelta := range []string{"00:61:00", "1:30", "00:00:60", "soon", "-00:01:00"} {
+		content := `{"laps": 2, "lapLen": 1000, "penaltyLen": 150, "firingLines": 2,
+			"start": "10:00:00", "startDelta": "` + startDelta + `"}`
+		if _, err := DecodeConfig(strings.NewReader(content)); err == nil {
+			t.Errorf("DecodeConfig() should reject startDelta %q", startDelta)
+		}
+	}
+
+	content := `{"laps": 2, "lapLen": 1000, "penaltyLen": 150, "firingLines": 2,
+		"date": "01.03.2024", "start": "10:00:00", "startDelta": "1m"}`
+	if _, err := DecodeConfig(strings.NewReader(content)); err == nil {
+		t.Error("DecodeConfig() should reject a date that is not in YYYY-MM-DD format")
+	}
+}
+
+func TestDecodeConfigMissPenalty(t *testing.T) {
+	content := `{"format": "individual", "laps": 5, "lapLen": 4000, "penaltyLen": 150, "firingLines": 4,
+		"start": "10:00:00.000", "startDelta": "00:01:30"}`
+
+	cfg, err := DecodeConfig(strings.NewReader(content))
+	if err != nil {
+		t.Fatalf("DecodeConfig() error = %v", err)
+	}
+	if cfg.MissPenalty != time.Minute {
+		t.Errorf("cfg.MissPenalty = %v, want the one minute default", cfg.MissPenalty)
+	}
+
+	content = strings.Replace(content, `"startDelta": "00:01:30"`, `"startDelta": "00:01:30", "missPenalty": "45s"`, 1)
+	cfg, err = DecodeConfig(strings.NewReader(content))
+	if err != nil {
+		t.Fatalf("DecodeConfig() error = %v", err)
+	}
+	if cfg.MissPenalty != 45*time.Second {
+		t.Errorf("cfg.MissPenalty = %v, want 45s", cfg.MissPenalty)
+	}
+}
@@ -0,0 +1,93 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+
+	"biathlon_events_parser/internal/models"
+	"biathlon_events_parser/internal/rules"
+)
+
+type FieldError struct {
+	Field  string
+	Reason string
+}
+
+func (e *FieldError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Field, e.Reason)
+}
+
+type ValidationError struct {
+	Problems []*FieldError
+}
+
+func (e *ValidationError) Error() string {
+	problems := make([]string, 0, len(e.Problems))
+	for _, p := range e.Problems {
+		problems = append(problems, p.Error())
+	}
+	return "invalid config: " + strings.Join(problems, "; ")
+}
+
+func (e *ValidationError) add(field, format string, args ...any) {
+	e.Problems = append(e.Problems, &FieldError{Field: field, Reason: fmt.Sprintf(format, args...)})
+}
+
+func (e *ValidationError) has(field string) bool {
+	for _, p := range e.Problems {
+		if p.Field == field {
+			return true
+		}
+	}
+	return false
+}
+
+func validate(cfg *models.Config, errs *ValidationError) {
+	positive := []struct {
+		field string
+		value int
+		skip  bool
+	}{
+		{"laps", cfg.Laps, false},
+		{"lapLen", cfg.LapLen, false},
+		{"penaltyLen", cfg.PenaltyLen, !rules.For(cfg).PenaltyLoops()},
+		{"firingLines", cfg.FiringLines, false},
+	}
+	for _, p := range positive {
+		if p.value <= 0 && !p.skip && !errs.has(p.field) {
+			errs.add(p.field, "must be positive, got %d", p.value)
+		}
+	}
+
+	r, ok := rules.Lookup(cfg)
+	if !ok {
+		errs.add("format", "unknown race format %q, want sprint, individual, pursuit, mass-start or relay", cfg.Format)
+	}
+
+	laps, firingLines := r.Stages()
+	switch {
+	case !ok:
+	case laps == 0:
+		if !errs.has("laps") && cfg.FiringLines > cfg.Laps {
+			errs.add("firingLines", "%d firing lines do not fit into %d laps", cfg.FiringLines, cfg.Laps)
+		}
+	default:
+		if cfg.Laps != laps && !errs.has("laps") {
+			errs.add("laps", "a %s race has %d laps, got %d", cfg.Format, laps, cfg.Laps)
+		}
+		if cfg.FiringLines != firingLines && !errs.has("firingLines") {
+			errs.add("firingLines", "a %s race has %d firing lines, got %d", cfg.Format, firingLines, cfg.FiringLines)
+		}
+	}
+
+	if cfg.StartDelta < 0 && !errs.has("startDelta") {
+		errs.add("startDelta", "must not be negative, got %v", cfg.StartDelta)
+	}
+
+	if cfg.MissPenalty < 0 && !errs.has("missPenalty") {
+		errs.add("missPenalty", "must not be negative, got %v", cfg.MissPenalty)
+	}
+	if ok && cfg.MissPenalty != 0 && r.PenaltyLoops() {
+		errs.add("missPenalty", "only applies to individual races, misses are served as penalty loops")
+	}
+}
@@ -0,0 +1,102 @@
+package eventparser
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"biathlon_events_parser/internal/models"
+)
+
+type Decoder struct {
+	scanner *bufio.Scanner
+	opts    Options
+	lineNum int
+	errs    []*ParseError
+	day     time.Time
+	last    time.Time
+}
+
+func NewDecoder(r io.Reader, opts Options) *Decoder {
+	day := time.Date(0, 1, 1, 0, 0, 0, 0, time.UTC)
+	if !opts.Date.IsZero() {
+		day = onDay(opts.Date, day)
+	}
+
+	return &Decoder{
+		scanner: bufio.NewScanner(r),
+		opts:    opts,
+		day:     day,
+	}
+}
+
+func (d *Decoder) Next() (*models.Event, error) {
+	for d.scanner.Scan() {
+		d.lineNum++
+		line := d.scanner.Text()
+		if line == "" {
+			continue
+		}
+
+		event, err := parseEventLine(line)
+		if err != nil {
+			var perr *ParseError
+			if !errors.As(err, &perr) {
+				return nil, err
+			}
+			perr.Line = d.lineNum
+			d.errs = append(d.errs, perr)
+			if d.opts.Diagnostics != nil {
+				fmt.Fprintf(d.opts.Diagnostics, "Error parsing %v\n", perr)
+			}
+
+			switch {
+			case d.opts.Mode == ModeStrict:
+				return nil, perr
+			case d.opts.Mode == ModeMaxErrors && len(d.errs) > d.opts.MaxErrors:
+				return nil, fmt.Errorf("%w: %d", ErrTooManyErrors, len(d.errs))
+			}
+			continue
+		}
+		event.Line = d.lineNum
+		d.timestamp(event)
+
+		return event, nil
+	}
+
+	if err := d.scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading file: %w", err)
+	}
+
+	return nil, io.EOF
+}
+
+func (d *Decoder) Errors() []*ParseError {
+	return d.errs
+}
+
+// timestamp moves the parsed times of day onto the race calendar. Events are
+// logged in order, so a clock that jumps back by more than half a day means
+// the race has passed midnight.
+func (d *Decoder) timestamp(ev *models.Event) {
+	ev.Time = onDay(d.day, ev.Time)
+	if !d.last.IsZero() && d.last.Sub(ev.Time) > 12*time.Hour {
+		d.day = d.day.AddDate(0, 0, 1)
+		ev.Time = ev.Time.AddDate(0, 0, 1)
+	}
+	d.last = ev.Time
+
+	if !ev.StartTime.IsZero() {
+		ev.StartTime = onDay(d.day, ev.StartTime)
+		if ev.Time.Sub(ev.StartTime) > 12*time.Hour {
+			ev.StartTime = ev.StartTime.AddDate(0, 0, 1)
+		}
+	}
+}
+
+func onDay(day, clock time.Time) time.Time {
+	y, m, dd := day.Date()
+	return time.Date(y, m, dd, clock.Hour(), clock.Minute(), clock.Second(), clock.Nanosecond(), time.UTC)
+}
@@ -0,0 +1,44 @@
+package eventparser
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+var ErrTooManyErrors = errors.New("too many parse errors")
+
+type Mode int
+
+const (
+	ModeLenient Mode = iota
+	ModeStrict
+	ModeMaxErrors
+)
+
+type Options struct {
+	Mode        Mode
+	MaxErrors   int
+	Diagnostics io.Writer
+	Date        time.Time
+}
+
+type ParseError struct {
+	Line   int
+	Column int
+	Text   string
+	Reason string
+}
+
+func newParseError(text string, column int, format string, args ...any) *ParseError {
+	return &ParseError{
+		Column: column,
+		Text:   text,
+		Reason: fmt.Sprintf(format, args...),
+	}
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("line %d, column %d: %s: %q", e.Line, e.Column, e.Reason, e.Text)
+}
@@ -1,84 +1,86 @@
 package eventparser
 
 import (
-	"bufio"
 	"fmt"
+	"io"
+	"io/fs"
 	"os"
 	"strconv"
 	"strings"
 	"time"
+	"unicode"
 
 	"biathlon_events_parser/internal/models"
+	"biathlon_events_parser/internal/paths"
 )
 
-func ParseEvents(filePath string) ([]*models.Event, error) {
-	homeDir, err := os.Getwd()
+func ParseEvents(filePath string, opts Options) ([]*models.Event, []*ParseError, error) {
+	path, err := paths.Resolve(filePath)
 	if err != nil {
-		return nil, err
+		return nil, nil, fmt.Errorf("failed to open event file: %w", err)
 	}
 
-	var path strings.Builder
-	path.WriteString(homeDir)
-	path.WriteString(filePath)
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open event file: %w", err)
+	}
+	defer file.Close()
+
+	return ReadEvents(file, opts)
+}
 
-	file, err := os.Open(path.String())
+func ParseEventsFS(fsys fs.FS, name string, opts Options) ([]*models.Event, []*ParseError, error) {
+	file, err := fsys.Open(name)
 	if err != nil {
-		return nil, fmt.Errorf("failed to open event file: %w", err)
+		return nil, nil, fmt.Errorf("failed to open event file: %w", err)
 	}
 	defer file.Close()
 
+	return ReadEvents(file, opts)
+}
+
+func ReadEvents(r io.Reader, opts Options) ([]*models.Event, []*ParseError, error) {
 	var events []*models.Event
-	scanner := bufio.NewScanner(file)
-	lineNum := 0
-	for scanner.Scan() {
-		lineNum++
-		line := scanner.Text()
-		if line == "" {
-			continue
+	dec := NewDecoder(r, opts)
+	for {
+		event, err := dec.Next()
+		if err == io.EOF {
+			break
 		}
-
-		event, err := parseEventLine(line)
 		if err != nil {
-			fmt.Printf("Error parsing line %d: %v\n", lineNum, err)
-			continue
+			return events, dec.Errors(), err
 		}
-
 		events = append(events, event)
 	}
 
-	if err := scanner.Err(); err != nil {
-		return nil, fmt.Errorf("error reading file: %w", err)
-	}
-
-	return events, nil
+	return events, dec.Errors(), nil
 }
 
 func parseEventLine(line string) (*models.Event, error) {
 	parts := strings.SplitN(line, "]", 2)
-	if len(parts) != 2 {
-		return nil, fmt.Errorf("invalid event line format: %q", line)
+	if len(parts) != 2 || !strings.HasPrefix(parts[0], "[") {
+		return nil, newParseError(line, 1, "invalid event line format")
 	}
 
-	timeStr := strings.Trim(parts[0], "[")
+	timeStr := parts[0][1:]
 	t, err := time.Parse("15:04:05.000", timeStr)
 	if err != nil {
-		return nil, fmt.Errorf("invalid event time format %q: %w", timeStr, err)
+		return nil, newParseError(line, 2, "invalid event time format %q", timeStr)
 	}
 
-	rest := strings.TrimSpace(parts[1])
-	fields := strings.Fields(rest)
+	fields := splitFields(parts[1], len(parts[0])+1)
 	if len(fields) < 2 {
-		return nil, fmt.Errorf("invalid number of event fields: %q", line)
+		return nil, newParseError(line, len(line)+1, "invalid number of event fields")
 	}
 
-	id, err := strconv.Atoi(fields[0])
+	id, err := strconv.Atoi(fields[0].text)
 	if err != nil {
-		return nil, fmt.Errorf("invalid event ID %q: %w", fields[0], err)
+		return nil, newParseError(line, fields[0].column, "invalid event ID %q", fields[0].text)
 	}
 
-	compID, err := strconv.Atoi(fields[1])
+	compID, err := strconv.Atoi(fields[1].text)
 	if err != nil {
-		return nil, fmt.Errorf("invalid competitor ID %q: %w", fields[1], err)
+		return nil, newParseError(line, fields[1].column, "invalid competitor ID %q", fields[1].text)
 	}
 
 	ev := models.Event{
@@ -90,33 +92,79 @@ func parseEventLine(line string) (*models.Event, error) {
 	switch id {
 	case 2:
 		if len(fields) < 3 {
-			return &ev, fmt.Errorf("missing start time parameter for event ID 2")
+			return nil, newParseError(line, len(line)+1, "missing start time parameter for event ID 2")
 		}
-		sched, err := time.Parse("15:04:05.000", fields[2])
+		sched, err := time.Parse("15:04:05.000", fields[2].text)
 		if err != nil {
-			return &ev, fmt.Errorf("invalid start time format %q: %w", fields[2], err)
+			return nil, newParseError(line, fields[2].column, "invalid start time format %q", fields[2].text)
 		}
 		ev.StartTime = sched
 
 	case 5:
 		if len(fields) >= 3 {
-			ev.FiringRange, err = strconv.Atoi(fields[2])
+			ev.FiringRange, err = strconv.Atoi(fields[2].text)
 			if err != nil {
-				return &ev, fmt.Errorf("invalid firing range %q: %w", fields[2], err)
+				return nil, newParseError(line, fields[2].column, "invalid firing range %q", fields[2].text)
 			}
 		}
 	case 6:
 		if len(fields) >= 3 {
-			ev.Target, err = strconv.Atoi(fields[2])
+			ev.Target, err = strconv.Atoi(fields[2].text)
 			if err != nil {
-				return &ev, fmt.Errorf("invalid target ID %q: %w", fields[2], err)
+				return nil, newParseError(line, fields[2].column, "invalid target ID %q", fields[2].text)
 			}
 		}
+	case 12:
+		if len(fields) < 3 {
+			return nil, newParseError(line, len(line)+1, "missing finish position parameter for event ID 12")
+		}
+		ev.Position, err = strconv.Atoi(fields[2].text)
+		if err != nil || ev.Position <= 0 {
+			return nil, newParseError(line, fields[2].column, "invalid finish position %q", fields[2].text)
+		}
+	case 13:
+		if len(fields) < 3 {
+			return nil, newParseError(line, len(line)+1, "missing next competitor parameter for event ID 13")
+		}
+		ev.NextID, err = strconv.Atoi(fields[2].text)
+		if err != nil {
+			return nil, newParseError(line, fields[2].column, "invalid competitor ID %q", fields[2].text)
+		}
 	case 11:
 		if len(fields) >= 3 {
-			ev.Comment = strings.Join(fields[2:], " ")
+			comment := make([]string, 0, len(fields)-2)
+			for _, f := range fields[2:] {
+				comment = append(comment, f.text)
+			}
+			ev.Comment = strings.Join(comment, " ")
 		}
 	}
 
 	return &ev, nil
 }
+
+type field struct {
+	text   string
+	column int
+}
+
+func splitFields(s string, offset int) []field {
+	var fields []field
+	start := -1
+	for i, r := range s {
+		if unicode.IsSpace(r) {
+			if start >= 0 {
+				fields = append(fields, field{text: s[start:i], column: offset + start + 1})
+				start = -1
+			}
+			continue
+		}
+		if start < 0 {
+			start = i
+		}
+	}
+	if start >= 0 {
+		fields = append(fields, field{text: s[start:], column: offset + start + 1})
+	}
+	return fields
+}
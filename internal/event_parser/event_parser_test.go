@@ -1,9 +1,16 @@
 package eventparser
 
 import (
+	"errors"
+	"io"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+	"testing/fstest"
+	"time"
+
+	"biathlon_events_parser/internal/models"
 )
 
 func TestParseEventLine(t *testing.T) {
@@ -31,6 +38,27 @@ func TestParseEventLine(t *testing.T) {
 			wantComp: 10,
 			wantTime: "09:30:00.000",
 		},
+		{
+			name:     "Success photo finish test",
+			input:    "[10:30:00.000] 12 10 2",
+			wantErr:  false,
+			wantID:   12,
+			wantComp: 10,
+			wantTime: "10:30:00.000",
+		},
+		{
+			name:    "Invalid photo finish position test",
+			input:   "[10:30:00.000] 12 10 0",
+			wantErr: true,
+		},
+		{
+			name:     "Success hand-over test",
+			input:    "[10:20:00.000] 13 1 2",
+			wantErr:  false,
+			wantID:   13,
+			wantComp: 1,
+			wantTime: "10:20:00.000",
+		},
 		{
 			name:    "Invalid format test",
 			input:   "09:30:00.000 1 10",
@@ -66,6 +94,9 @@ func TestParseEventLine(t *testing.T) {
 				return
 			}
 			if err != nil {
+				if got != nil {
+					t.Errorf("parseEventLine() returned event %v together with error", got)
+				}
 				return
 			}
 
@@ -106,11 +137,15 @@ func TestParseEvents(t *testing.T) {
 
 	os.Chdir(tmpDir)
 
-	events, err := ParseEvents("/test_events.txt")
+	events, parseErrs, err := ParseEvents("test_events.txt", Options{})
 	if err != nil {
 		t.Fatalf("ParseEvents() error = %v", err)
 	}
 
+	if len(parseErrs) != 0 {
+		t.Errorf("ParseEvents() returned %d parse errors, want 0", len(parseErrs))
+	}
+
 	if len(events) != 4 {
 		t.Errorf("ParseEvents() returned %d events, want 4", len(events))
 	}
@@ -127,12 +162,210 @@ func TestParseEvents(t *testing.T) {
 			t.Errorf("Event %d has wrong time: got %s, want %s",
 				i, event.Time.Format("15:04:05.000"), wantTimes[i])
 		}
+		if event.Line != i+1 {
+			t.Errorf("Event %d has wrong line: got %d, want %d", i, event.Line, i+1)
+		}
 	}
 }
 
 func TestParseEventsFileErrors(t *testing.T) {
-	_, err := ParseEvents("/does_not_exist.txt")
+	_, _, err := ParseEvents(filepath.Join(t.TempDir(), "does_not_exist.txt"), Options{})
 	if err == nil {
 		t.Error("ParseEvents() should return error for non-existent file")
 	}
 }
+
+func TestParseEventsAbsolutePath(t *testing.T) {
+	testFile := filepath.Join(t.TempDir(), "events")
+	if err := os.WriteFile(testFile, []byte("[09:30:00.000] 1 10\n"), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	events, _, err := ParseEvents(testFile, Options{})
+	if err != nil {
+		t.Fatalf("ParseEvents(%q) error = %v", testFile, err)
+	}
+	if len(events) != 1 {
+		t.Errorf("ParseEvents() returned %d events, want 1", len(events))
+	}
+}
+
+func TestParseEventsFS(t *testing.T) {
+	fsys := fstest.MapFS{
+		"events": {Data: []byte("[09:30:00.000] 1 10\nbroken\n[09:30:05.000] 2 10 10:00:00.000\n")},
+	}
+
+	events, parseErrs, err := ParseEventsFS(fsys, "events", Options{})
+	if err != nil {
+		t.Fatalf("ParseEventsFS() error = %v", err)
+	}
+	if len(events) != 2 || len(parseErrs) != 1 {
+		t.Errorf("ParseEventsFS() returned %d events and %d parse errors, want 2 and 1", len(events), len(parseErrs))
+	}
+}
+
+func TestDecoder(t *testing.T) {
+	input := `[09:30:00.000] 1 10
+
+invalid line
+[09:30:05.000] 2 10 10:00:00.000
+[09:30:10.000] 3 10`
+
+	dec := NewDecoder(strings.NewReader(input), Options{})
+
+	wantLines := []int{1, 4, 5}
+	wantIDs := []int{1, 2, 3}
+	for i := range wantLines {
+		ev, err := dec.Next()
+		if err != nil {
+			t.Fatalf("Next() error = %v", err)
+		}
+		if ev.Line != wantLines[i] || ev.EventID != wantIDs[i] {
+			t.Errorf("Next() = event %d on line %d, want event %d on line %d",
+				ev.EventID, ev.Line, wantIDs[i], wantLines[i])
+		}
+	}
+
+	if _, err := dec.Next(); err != io.EOF {
+		t.Errorf("Next() at end of input error = %v, want io.EOF", err)
+	}
+}
+
+func TestParseErrorColumns(t *testing.T) {
+	tests := []struct {
+		input      string
+		wantColumn int
+	}{
+		{input: "09:30:00.000 1 10", wantColumn: 1},
+		{input: "[09:30:00] 1 10", wantColumn: 2},
+		{input: "[09:30:00.000] X 10", wantColumn: 16},
+		{input: "[09:30:00.000] 1  X", wantColumn: 19},
+		{input: "[09:30:00.000] 2 10 10:00", wantColumn: 21},
+		{input: "[09:30:00.000] 1", wantColumn: 17},
+		{input: "[10:30:00.000] 12 10", wantColumn: 21},
+		{input: "[10:30:00.000] 12 10 first", wantColumn: 22},
+		{input: "[10:20:00.000] 13 1", wantColumn: 20},
+		{input: "[10:20:00.000] 13 1 X", wantColumn: 21},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			_, err := parseEventLine(tt.input)
+			var perr *ParseError
+			if !errors.As(err, &perr) {
+				t.Fatalf("parseEventLine() error = %v, want *ParseError", err)
+			}
+			if perr.Column != tt.wantColumn {
+				t.Errorf("ParseError.Column = %d, want %d", perr.Column, tt.wantColumn)
+			}
+			if perr.Text != tt.input || perr.Reason == "" {
+				t.Errorf("ParseError = %+v, want raw text and a reason", perr)
+			}
+		})
+	}
+}
+
+func TestDecoderModes(t *testing.T) {
+	input := `[09:30:00.000] 1 10
+bad line one
+[09:30:05.000] 1 20
+bad line two
+bad line three
+[09:30:10.000] 1 30`
+
+	tests := []struct {
+		name       string
+		opts       Options
+		wantEvents int
+		wantErrs   int
+		wantErr    error
+	}{
+		{name: "Lenient", opts: Options{Mode: ModeLenient}, wantEvents: 3, wantErrs: 3},
+		{name: "Strict", opts: Options{Mode: ModeStrict}, wantEvents: 1, wantErrs: 1},
+		{name: "Max errors reached", opts: Options{Mode: ModeMaxErrors, MaxErrors: 3}, wantEvents: 3, wantErrs: 3, wantErr: io.EOF},
+		{name: "Max errors exceeded", opts: Options{Mode: ModeMaxErrors, MaxErrors: 2}, wantEvents: 2, wantErrs: 3, wantErr: ErrTooManyErrors},
+		{name: "One error allowed", opts: Options{Mode: ModeMaxErrors, MaxErrors: 1}, wantEvents: 2, wantErrs: 2, wantErr: ErrTooManyErrors},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dec := NewDecoder(strings.NewReader(input), tt.opts)
+
+			events := 0
+			var err error
+			for {
+				_, err = dec.Next()
+				if err != nil {
+					break
+				}
+				events++
+			}
+
+			if events != tt.wantEvents {
+				t.Errorf("decoded %d events, want %d", events, tt.wantEvents)
+			}
+			if len(dec.Errors()) != tt.wantErrs {
+				t.Errorf("Errors() returned %d errors, want %d", len(dec.Errors()), tt.wantErrs)
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Errorf("Next() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.opts.Mode == ModeLenient && err != io.EOF {
+				t.Errorf("Next() error = %v, want io.EOF", err)
+			}
+			if errs := dec.Errors(); len(errs) > 0 && errs[0].Line != 2 {
+				t.Errorf("first ParseError.Line = %d, want 2", errs[0].Line)
+			}
+		})
+	}
+}
+
+func TestDecoderDiagnostics(t *testing.T) {
+	var diag strings.Builder
+	dec := NewDecoder(strings.NewReader("[09:30:00.000] 1 10\nbad line\n"), Options{Diagnostics: &diag})
+
+	for {
+		if _, err := dec.Next(); err != nil {
+			break
+		}
+	}
+
+	if !strings.Contains(diag.String(), "Error parsing line 2, column 1") {
+		t.Errorf("diagnostics = %q, want the error for line 2", diag.String())
+	}
+}
+
+func TestDecoderTimestamps(t *testing.T) {
+	input := `[23:50:00.000] 1 10
+[23:55:00.000] 2 10 00:05:00.000
+[23:59:59.500] 3 10
+[00:05:00.100] 4 10`
+
+	date := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	dec := NewDecoder(strings.NewReader(input), Options{Date: date})
+
+	var events []*models.Event
+	for {
+		ev, err := dec.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Next() error = %v", err)
+		}
+		events = append(events, ev)
+	}
+
+	if !events[0].Time.Equal(time.Date(2024, 3, 1, 23, 50, 0, 0, time.UTC)) {
+		t.Errorf("events[0].Time = %v, want the race date", events[0].Time)
+	}
+	if want := time.Date(2024, 3, 2, 0, 5, 0, 0, time.UTC); !events[1].StartTime.Equal(want) {
+		t.Errorf("events[1].StartTime = %v, want %v", events[1].StartTime, want)
+	}
+	if want := time.Date(2024, 3, 2, 0, 5, 0, 100_000_000, time.UTC); !events[3].Time.Equal(want) {
+		t.Errorf("events[3].Time = %v, want %v after midnight", events[3].Time, want)
+	}
+	if got := events[3].Time.Sub(events[2].Time); got != 5*time.Minute+600*time.Millisecond {
+		t.Errorf("time between the last two events = %v, want 5m0.6s", got)
+	}
+}
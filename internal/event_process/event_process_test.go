@@ -1,6 +1,8 @@
 package eventprocess
 
 import (
+	"io"
+	"strings"
 	"testing"
 	"time"
 
@@ -16,25 +18,45 @@ func newTestEvent(timeStr string, eventID, compID int) *models.Event {
 	}
 }
 
+func newScheduledEvent(timeStr string, compID int, start string) *models.Event {
+	ev := newTestEvent(timeStr, 2, compID)
+	ev.StartTime, _ = time.Parse("15:04:05.000", start)
+	return ev
+}
+
+func withRange(ev *models.Event, firingRange int) *models.Event {
+	ev.FiringRange = firingRange
+	return ev
+}
+
+func withTarget(ev *models.Event, target int) *models.Event {
+	ev.Target = target
+	return ev
+}
+
 func TestProcessEvents(t *testing.T) {
 	cfg := &models.Config{
-		Laps:       3,
-		LapLen:     1000,
-		PenaltyLen: 150,
+		Laps:        1,
+		LapLen:      1000,
+		PenaltyLen:  150,
+		FiringLines: 1,
 	}
 
 	events := []*models.Event{
 		newTestEvent("10:00:00.000", 1, 10),
-		newTestEvent("10:01:00.000", 2, 10),
+		newScheduledEvent("10:01:00.000", 10, "10:03:00.000"),
 		newTestEvent("10:02:00.000", 3, 10),
 		newTestEvent("10:03:00.000", 4, 10),
-		newTestEvent("10:04:00.000", 5, 10),
-		newTestEvent("10:04:10.000", 6, 10),
+		withRange(newTestEvent("10:04:00.000", 5, 10), 1),
+		withTarget(newTestEvent("10:04:10.000", 6, 10), 1),
 		newTestEvent("10:04:20.000", 7, 10),
 		newTestEvent("10:05:00.000", 10, 10),
 	}
 
-	competitors := ProcessEvents(events, cfg)
+	competitors, _, err := ProcessEvents(io.Discard, events, cfg, Options{})
+	if err != nil {
+		t.Fatalf("ProcessEvents() error = %v", err)
+	}
 
 	if len(competitors) != 1 {
 		t.Errorf("ProcessEvents() returned %d competitors, want 1", len(competitors))
@@ -68,8 +90,8 @@ func TestProcessEvents(t *testing.T) {
 		t.Errorf("LapSpeed = %v, want %v", comp.LapSpeeds[0], expectedSpeed)
 	}
 
-	if comp.Hits != 1 {
-		t.Errorf("Hits = %d, want 1", comp.Hits)
+	if comp.Hits() != 1 {
+		t.Errorf("Hits = %d, want 1", comp.Hits())
 	}
 
 	if comp.Status != "[Finished]" {
@@ -86,15 +108,22 @@ func TestProcessEventsMultipleCompetitors(t *testing.T) {
 
 	events := []*models.Event{
 		newTestEvent("10:00:00.000", 1, 10),
+		newScheduledEvent("10:00:10.000", 10, "10:01:00.000"),
+		newTestEvent("10:00:50.000", 3, 10),
 		newTestEvent("10:01:00.000", 4, 10),
 
 		newTestEvent("10:00:30.000", 1, 20),
+		newScheduledEvent("10:00:40.000", 20, "10:01:30.000"),
+		newTestEvent("10:01:20.000", 3, 20),
 		newTestEvent("10:01:30.000", 4, 20),
 		newTestEvent("10:02:00.000", 10, 10),
 		newTestEvent("10:02:30.000", 10, 20),
 	}
 
-	competitors := ProcessEvents(events, cfg)
+	competitors, _, err := ProcessEvents(io.Discard, events, cfg, Options{})
+	if err != nil {
+		t.Fatalf("ProcessEvents() error = %v", err)
+	}
 
 	if len(competitors) != 2 {
 		t.Errorf("ProcessEvents() returned %d competitors, want 2", len(competitors))
@@ -120,20 +149,28 @@ func TestProcessEventsMultipleCompetitors(t *testing.T) {
 
 func TestProcessEventsPenalty(t *testing.T) {
 	cfg := &models.Config{
-		Laps:       3,
-		LapLen:     1000,
-		PenaltyLen: 150,
+		Laps:        3,
+		LapLen:      1000,
+		PenaltyLen:  150,
+		FiringLines: 1,
 	}
 
 	events := []*models.Event{
 		newTestEvent("10:00:00.000", 1, 10),
+		newScheduledEvent("10:00:10.000", 10, "10:01:00.000"),
+		newTestEvent("10:00:50.000", 3, 10),
 		newTestEvent("10:01:00.000", 4, 10),
+		withRange(newTestEvent("10:01:30.000", 5, 10), 1),
+		newTestEvent("10:01:50.000", 7, 10),
 		newTestEvent("10:02:00.000", 8, 10),
 		newTestEvent("10:02:30.000", 9, 10),
 		newTestEvent("10:03:00.000", 10, 10),
 	}
 
-	competitors := ProcessEvents(events, cfg)
+	competitors, _, err := ProcessEvents(io.Discard, events, cfg, Options{})
+	if err != nil {
+		t.Fatalf("ProcessEvents() error = %v", err)
+	}
 	comp := competitors[10]
 
 	expectedPenalty := 30 * time.Second
@@ -145,4 +182,961 @@ func TestProcessEventsPenalty(t *testing.T) {
 	if comp.PenaltySpeed != expectedPenaltySpeed {
 		t.Errorf("PenaltySpeed = %v, want %v", comp.PenaltySpeed, expectedPenaltySpeed)
 	}
+
+	expectedLapTime := 2 * time.Minute
+	if comp.LapTimes[0] != expectedLapTime {
+		t.Errorf("LapTime = %v, want %v", comp.LapTimes[0], expectedLapTime)
+	}
+
+	if len(comp.RangeTimes) != 1 || comp.RangeTimes[0] != 20*time.Second {
+		t.Errorf("RangeTimes = %v, want [20s]", comp.RangeTimes)
+	}
+
+	if len(comp.PenaltyTimes) != 1 || comp.PenaltyTimes[0] != expectedPenalty {
+		t.Errorf("PenaltyTimes = %v, want [%v]", comp.PenaltyTimes, expectedPenalty)
+	}
+
+	expectedCourse := expectedLapTime - 20*time.Second - expectedPenalty
+	if comp.CourseTime != expectedCourse {
+		t.Errorf("CourseTime = %v, want %v", comp.CourseTime, expectedCourse)
+	}
+
+	expectedCourseSpeed := float64(cfg.LapLen) / expectedCourse.Seconds()
+	if comp.CourseSpeed != expectedCourseSpeed {
+		t.Errorf("CourseSpeed = %v, want %v", comp.CourseSpeed, expectedCourseSpeed)
+	}
+}
+
+func TestProcessEventsOutgoing(t *testing.T) {
+	cfg := &models.Config{
+		Laps:       2,
+		LapLen:     1000,
+		PenaltyLen: 150,
+		StartDelta: time.Minute,
+	}
+
+	events := []*models.Event{
+		newTestEvent("09:50:00.000", 1, 10),
+		newTestEvent("09:50:10.000", 1, 20),
+		newTestEvent("09:50:20.000", 1, 30),
+		newScheduledEvent("09:55:00.000", 10, "10:00:00.000"),
+		newScheduledEvent("09:55:10.000", 20, "10:01:00.000"),
+		newScheduledEvent("09:55:20.000", 30, "10:02:00.000"),
+		newTestEvent("09:59:00.000", 3, 10),
+		newTestEvent("10:00:00.000", 4, 10),
+		newTestEvent("10:02:00.000", 3, 30),
+		newTestEvent("10:02:30.000", 4, 30),
+		newTestEvent("10:05:00.000", 10, 10),
+		newTestEvent("10:07:30.000", 10, 30),
+		newTestEvent("10:10:00.000", 10, 10),
+	}
+
+	competitors, log, _, _ := processEvents(nil, events, cfg, Options{})
+
+	wantStatus := map[int]string{
+		10: models.StatusFinished,
+		20: models.StatusNotStarted,
+		30: models.StatusNotFinished,
+	}
+	for id, want := range wantStatus {
+		if got := competitors[id].Status; got != want {
+			t.Errorf("Competitor %d Status = %s, want %s", id, got, want)
+		}
+	}
+
+	wantLog := map[string]bool{
+		"[10:02:00.000] Competitor(20) is disqualified": false,
+		"[10:10:00.000] Competitor(10) has finished":    false,
+	}
+	for i, entry := range log {
+		if i > 0 && entry.Time.Before(log[i-1].Time) {
+			t.Errorf("log entry %q is out of time order", entry.Message)
+		}
+		if _, ok := wantLog[entry.Message]; ok {
+			wantLog[entry.Message] = true
+		}
+	}
+	for msg, found := range wantLog {
+		if !found {
+			t.Errorf("log does not contain %q", msg)
+		}
+	}
+}
+
+func TestProcessEventsStartWindow(t *testing.T) {
+	cfg := &models.Config{
+		Laps:       1,
+		LapLen:     1000,
+		PenaltyLen: 150,
+		StartDelta: 90 * time.Second,
+	}
+
+	events := []*models.Event{
+		newTestEvent("09:50:00.000", 1, 10),
+		newTestEvent("09:50:10.000", 1, 20),
+		newTestEvent("09:50:20.000", 1, 30),
+		newScheduledEvent("09:55:00.000", 10, "10:00:00.000"),
+		newScheduledEvent("09:55:10.000", 20, "10:01:00.000"),
+		newScheduledEvent("09:55:20.000", 30, "10:02:00.000"),
+		newTestEvent("10:00:00.000", 3, 10),
+		newTestEvent("10:01:30.000", 4, 10),
+		newTestEvent("10:01:00.000", 3, 20),
+		newTestEvent("10:02:31.000", 4, 20),
+		newTestEvent("10:05:00.000", 10, 10),
+		newTestEvent("10:06:00.000", 10, 20),
+	}
+
+	competitors, _, _, _ := processEvents(nil, events, cfg, Options{})
+
+	tests := []struct {
+		id             int
+		wantStatus     string
+		wantDisqualify string
+	}{
+		{id: 10, wantStatus: models.StatusFinished},
+		{id: 20, wantStatus: models.StatusDisqualified, wantDisqualify: "10:02:30.000"},
+		{id: 30, wantStatus: models.StatusNotStarted, wantDisqualify: "10:03:30.000"},
+	}
+
+	for _, tt := range tests {
+		comp := competitors[tt.id]
+		if comp.Status != tt.wantStatus {
+			t.Errorf("Competitor %d Status = %s, want %s", tt.id, comp.Status, tt.wantStatus)
+		}
+
+		got := ""
+		if !comp.DisqualifiedAt.IsZero() {
+			got = comp.DisqualifiedAt.Format("15:04:05.000")
+		}
+		if got != tt.wantDisqualify {
+			t.Errorf("Competitor %d DisqualifiedAt = %q, want %q", tt.id, got, tt.wantDisqualify)
+		}
+	}
+}
+
+func TestProcessEventsViolations(t *testing.T) {
+	cfg := &models.Config{
+		Laps:       1,
+		LapLen:     1000,
+		PenaltyLen: 150,
+	}
+
+	withLine := func(ev *models.Event, line int) *models.Event {
+		ev.Line = line
+		return ev
+	}
+
+	events := []*models.Event{
+		withLine(newTestEvent("09:50:00.000", 1, 10), 1),
+		withLine(newTestEvent("09:51:00.000", 10, 10), 2),
+		withLine(newScheduledEvent("09:55:00.000", 10, "10:00:00.000"), 3),
+		withLine(newTestEvent("09:59:00.000", 3, 10), 4),
+		withLine(newTestEvent("10:00:00.000", 4, 10), 5),
+		withLine(newTestEvent("10:01:00.000", 6, 10), 6),
+		withLine(newTestEvent("10:02:00.000", 9, 10), 7),
+		withLine(newTestEvent("10:03:00.000", 10, 10), 8),
+		withLine(newTestEvent("10:04:00.000", 5, 10), 9),
+		withLine(newTestEvent("10:05:00.000", 4, 20), 10),
+	}
+
+	competitors, _, violations, err := processEvents(nil, events, cfg, Options{})
+	if err != nil {
+		t.Fatalf("processEvents() error = %v", err)
+	}
+
+	wantLines := []int{2, 6, 7, 9, 10}
+	if len(violations) != len(wantLines) {
+		t.Fatalf("processEvents() returned %d violations, want %d", len(violations), len(wantLines))
+	}
+	for i, v := range violations {
+		if v.Line != wantLines[i] {
+			t.Errorf("violation %d Line = %d, want %d", i, v.Line, wantLines[i])
+		}
+		if v.Reason == "" {
+			t.Errorf("violation %d has empty Reason", i)
+		}
+	}
+
+	if comp := competitors[10]; comp.Status != models.StatusFinished || comp.Hits() != 0 {
+		t.Errorf("Competitor 10 Status = %s, Hits = %d, want [Finished] and 0", comp.Status, comp.Hits())
+	}
+	if _, exists := competitors[20]; exists {
+		t.Error("Competitor 20 should not be created by a rejected event")
+	}
+
+	_, _, violations, err = processEvents(nil, events, cfg, Options{Strict: true})
+	if err == nil {
+		t.Fatal("processEvents() in strict mode should return error")
+	}
+	if len(violations) != 1 || violations[0].Line != 2 {
+		t.Errorf("strict mode should stop at the violation on line 2, got %v", violations)
+	}
+}
+
+func TestProcessEventsRangeVisits(t *testing.T) {
+	cfg := &models.Config{
+		Laps:        2,
+		LapLen:      1000,
+		PenaltyLen:  150,
+		FiringLines: 2,
+	}
+
+	hit := func(timeStr string, target int) *models.Event {
+		ev := newTestEvent(timeStr, 6, 10)
+		ev.Target = target
+		return ev
+	}
+
+	events := []*models.Event{
+		newTestEvent("09:50:00.000", 1, 10),
+		newScheduledEvent("09:55:00.000", 10, "10:00:00.000"),
+		newTestEvent("09:59:00.000", 3, 10),
+		newTestEvent("10:00:00.000", 4, 10),
+		withRange(newTestEvent("10:01:00.000", 5, 10), 1),
+		hit("10:01:01.000", 1),
+		hit("10:01:02.000", 2),
+		hit("10:01:03.000", 3),
+		hit("10:01:04.000", 4),
+		hit("10:01:05.000", 5),
+		newTestEvent("10:01:10.000", 7, 10),
+		newTestEvent("10:02:00.000", 10, 10),
+		withRange(newTestEvent("10:03:00.000", 5, 10), 2),
+		hit("10:03:01.000", 2),
+		hit("10:03:02.000", 2),
+		hit("10:03:03.000", 4),
+		newTestEvent("10:03:10.000", 7, 10),
+		newTestEvent("10:04:00.000", 10, 10),
+	}
+
+	competitors, _, violations, err := processEvents(nil, events, cfg, Options{})
+	if err != nil {
+		t.Fatalf("processEvents() error = %v", err)
+	}
+	if len(violations) != 1 || violations[0].Reason != "target 2 is already hit" {
+		t.Errorf("violations = %v, want the second hit on target 2 rejected", violations)
+	}
+
+	comp := competitors[10]
+	if len(comp.RangeVisits) != 2 {
+		t.Fatalf("RangeVisits count = %d, want 2", len(comp.RangeVisits))
+	}
+
+	wantMisses := []int{0, 3}
+	for i, v := range comp.RangeVisits {
+		if v.Misses() != wantMisses[i] {
+			t.Errorf("RangeVisits[%d] Misses = %d, want %d", i, v.Misses(), wantMisses[i])
+		}
+		if v.Leave.IsZero() {
+			t.Errorf("RangeVisits[%d] Leave is not set", i)
+		}
+	}
+
+	if comp.Hits() != 7 {
+		t.Errorf("Hits = %d, want 7", comp.Hits())
+	}
+}
+
+func TestProcessEventsPenaltyLaps(t *testing.T) {
+	cfg := &models.Config{
+		Laps:        1,
+		LapLen:      1000,
+		PenaltyLen:  100,
+		FiringLines: 1,
+	}
+
+	hit := func(timeStr string, target int) *models.Event {
+		ev := newTestEvent(timeStr, 6, 10)
+		ev.Target = target
+		return ev
+	}
+
+	enterRange := newTestEvent("10:01:00.000", 5, 10)
+	enterRange.FiringRange = 1
+
+	events := []*models.Event{
+		newTestEvent("09:50:00.000", 1, 10),
+		newScheduledEvent("09:55:00.000", 10, "10:00:00.000"),
+		newTestEvent("09:59:00.000", 3, 10),
+		newTestEvent("10:00:00.000", 4, 10),
+		enterRange,
+		hit("10:01:01.000", 1),
+		hit("10:01:02.000", 2),
+		newTestEvent("10:01:10.000", 7, 10),
+		newTestEvent("10:01:20.000", 8, 10),
+		newTestEvent("10:01:40.000", 9, 10),
+		newTestEvent("10:03:20.000", 10, 10),
+	}
+
+	competitors, log, _, err := processEvents(nil, events, cfg, Options{})
+	if err != nil {
+		t.Fatalf("processEvents() error = %v", err)
+	}
+
+	served, required := competitors[10].PenaltyLaps()
+	if served != 1 || required != 3 {
+		t.Errorf("PenaltyLaps() = %d/%d, want 1/3", served, required)
+	}
+
+	want := "[10:03:20.000] Competitor(10) served 1 of 3 penalty laps at firing range(1)"
+	found := false
+	for _, entry := range log {
+		if entry.Message == want {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("log does not contain %q", want)
+	}
+
+	cfg.Format = models.FormatIndividual
+	_, log, violations, err := processEvents(nil, events, cfg, Options{})
+	if err != nil {
+		t.Fatalf("processEvents() error = %v", err)
+	}
+	for _, entry := range log {
+		if strings.Contains(entry.Message, "penalty laps") {
+			t.Errorf("individual race should not check penalty laps, got %q", entry.Message)
+		}
+	}
+
+	wantReasons := []string{
+		"event 8 is not allowed, individual races have no penalty loops",
+		"event 9 is not allowed, individual races have no penalty loops",
+	}
+	if len(violations) != len(wantReasons) {
+		t.Fatalf("individual race violations = %v, want events 8 and 9 rejected", violations)
+	}
+	for i, want := range wantReasons {
+		if violations[i].Reason != want {
+			t.Errorf("violations[%d].Reason = %q, want %q", i, violations[i].Reason, want)
+		}
+	}
+}
+
+func TestProcessEventsPenaltyWithoutRange(t *testing.T) {
+	cfg := &models.Config{Laps: 1, LapLen: 1000, PenaltyLen: 150}
+
+	events := []*models.Event{
+		newTestEvent("09:50:00.000", 1, 10),
+		newScheduledEvent("09:55:00.000", 10, "10:00:00.000"),
+		newTestEvent("09:59:00.000", 3, 10),
+		newTestEvent("10:00:00.000", 4, 10),
+		newTestEvent("10:01:00.000", 8, 10),
+	}
+
+	_, _, violations, _ := processEvents(nil, events, cfg, Options{})
+	if len(violations) != 1 || violations[0].EventID != 8 {
+		t.Errorf("processEvents() violations = %v, want one for event 8", violations)
+	}
+}
+
+func TestProcessEventsLapCount(t *testing.T) {
+	cfg := &models.Config{Laps: 2, LapLen: 1000, PenaltyLen: 150}
+
+	events := []*models.Event{
+		newTestEvent("09:50:00.000", 1, 10),
+		newTestEvent("09:50:10.000", 1, 20),
+		newScheduledEvent("09:55:00.000", 10, "10:00:00.000"),
+		newScheduledEvent("09:55:10.000", 20, "10:00:30.000"),
+		newTestEvent("09:59:00.000", 3, 10),
+		newTestEvent("10:00:00.000", 4, 10),
+		newTestEvent("10:00:10.000", 3, 20),
+		newTestEvent("10:00:30.000", 4, 20),
+		newTestEvent("10:02:00.000", 10, 10),
+		newTestEvent("10:02:30.000", 10, 20),
+		newTestEvent("10:04:00.000", 10, 10),
+		newTestEvent("10:06:00.000", 10, 10),
+	}
+
+	competitors, _, violations, err := processEvents(nil, events, cfg, Options{})
+	if err != nil {
+		t.Fatalf("processEvents() error = %v", err)
+	}
+
+	if len(violations) != 1 || !strings.Contains(violations[0].Reason, "extra lap 3") {
+		t.Errorf("processEvents() violations = %v, want one extra lap", violations)
+	}
+
+	if comp := competitors[10]; comp.Status != models.StatusFinished || len(comp.LapTimes) != 2 {
+		t.Errorf("Competitor 10 Status = %s with %d laps, want [Finished] with 2", comp.Status, len(comp.LapTimes))
+	}
+
+	comp := competitors[20]
+	if comp.Status != models.StatusNotFinished {
+		t.Errorf("Competitor 20 Status = %s, want [NotFinished]", comp.Status)
+	}
+	if comp.Comment != "completed 1 of 2 laps" {
+		t.Errorf("Competitor 20 Comment = %q, want %q", comp.Comment, "completed 1 of 2 laps")
+	}
+}
+
+func TestProcessEventsZeroLap(t *testing.T) {
+	cfg := &models.Config{Laps: 2, LapLen: 1000, PenaltyLen: 150}
+
+	events := []*models.Event{
+		newTestEvent("09:50:00.000", 1, 10),
+		newScheduledEvent("09:55:00.000", 10, "10:00:00.000"),
+		newTestEvent("09:59:00.000", 3, 10),
+		newTestEvent("10:00:00.000", 4, 10),
+		newTestEvent("10:00:00.000", 10, 10),
+		newTestEvent("10:02:00.000", 10, 10),
+	}
+
+	competitors, _, violations, err := processEvents(nil, events, cfg, Options{})
+	if err != nil {
+		t.Fatalf("processEvents() error = %v", err)
+	}
+	if len(violations) != 0 {
+		t.Fatalf("processEvents() violations = %v, want none", violations)
+	}
+
+	comp := competitors[10]
+	if comp.LapSpeeds[0] != 0 {
+		t.Errorf("LapSpeeds[0] = %v, want 0 for a lap without time", comp.LapSpeeds[0])
+	}
+	if comp.CourseSpeed != 1000.0/60 {
+		t.Errorf("CourseSpeed = %v, want %v", comp.CourseSpeed, 1000.0/60)
+	}
+}
+
+func TestProcessorLive(t *testing.T) {
+	cfg := &models.Config{
+		Laps:       2,
+		LapLen:     1000,
+		PenaltyLen: 150,
+		StartDelta: time.Minute,
+	}
+
+	p := NewProcessor(cfg, nil)
+	apply := func(ev *models.Event) {
+		t.Helper()
+		if err := p.Apply(ev); err != nil {
+			t.Fatalf("Apply() error = %v", err)
+		}
+	}
+
+	apply(newTestEvent("09:50:00.000", 1, 10))
+	apply(newTestEvent("09:50:10.000", 1, 20))
+	apply(newScheduledEvent("09:55:00.000", 10, "10:00:00.000"))
+	apply(newScheduledEvent("09:55:10.000", 20, "10:01:00.000"))
+	apply(newTestEvent("09:59:00.000", 3, 10))
+	apply(newTestEvent("10:00:00.000", 4, 10))
+	apply(newTestEvent("10:03:00.000", 10, 10))
+
+	comp, ok := p.Competitor(10)
+	if !ok {
+		t.Fatal("Competitor(10) not found")
+	}
+	if comp.Status != "" || len(comp.LapTimes) != 1 {
+		t.Errorf("Competitor 10 mid-race Status = %q with %d laps, want racing with 1 lap",
+			comp.Status, len(comp.LapTimes))
+	}
+
+	missed, _ := p.Competitor(20)
+	if missed.Status != models.StatusNotStarted || missed.DisqualifiedAt.Format("15:04:05.000") != "10:02:00.000" {
+		t.Errorf("Competitor 20 mid-race Status = %q, DisqualifiedAt = %v, want disqualified at 10:02:00.000",
+			missed.Status, missed.DisqualifiedAt)
+	}
+
+	if err := p.Apply(newTestEvent("10:04:00.000", 10, 30)); err == nil {
+		t.Error("Apply() should reject an event for an unregistered competitor")
+	}
+
+	apply(newTestEvent("10:06:00.000", 10, 10))
+	competitors := p.Finalize()
+	if competitors[10].Status != models.StatusFinished {
+		t.Errorf("Competitor 10 Status = %s, want [Finished]", competitors[10].Status)
+	}
+
+	if err := p.Apply(newTestEvent("10:07:00.000", 1, 40)); err != ErrFinalized {
+		t.Errorf("Apply() after Finalize() error = %v, want %v", err, ErrFinalized)
+	}
+}
+
+func TestProcessEventsOutput(t *testing.T) {
+	cfg := &models.Config{Laps: 1, LapLen: 1000, PenaltyLen: 150}
+
+	events := []*models.Event{
+		newTestEvent("09:50:00.000", 1, 10),
+		newTestEvent("09:51:00.000", 4, 10),
+	}
+
+	var out, diag strings.Builder
+	if _, _, err := ProcessEvents(&out, events, cfg, Options{Diagnostics: &diag}); err != nil {
+		t.Fatalf("ProcessEvents() error = %v", err)
+	}
+
+	want := "[09:50:00.000] Competitor(10) has registered\n"
+	if out.String() != want {
+		t.Errorf("ProcessEvents() output = %q, want %q", out.String(), want)
+	}
+
+	want = "Rejected event at line 0: [09:51:00.000] Competitor(10): event 4 is not allowed while competitor is registered\n"
+	if diag.String() != want {
+		t.Errorf("ProcessEvents() diagnostics = %q, want %q", diag.String(), want)
+	}
+}
+
+func TestProcessEventsRoster(t *testing.T) {
+	cfg := &models.Config{Laps: 1, LapLen: 1000, PenaltyLen: 150}
+	roster := models.Roster{
+		10: {ID: 10, Name: "Anna Berg", Nation: "NOR", Bib: 7},
+	}
+
+	events := []*models.Event{
+		newTestEvent("09:50:00.000", 1, 10),
+		newTestEvent("09:51:00.000", 1, 20),
+		newTestEvent("09:52:00.000", 1, 20),
+	}
+
+	var out, diag strings.Builder
+	competitors, violations, err := ProcessEvents(&out, events, cfg, Options{Roster: roster, Diagnostics: &diag})
+	if err != nil {
+		t.Fatalf("ProcessEvents() error = %v", err)
+	}
+
+	comp := competitors[10]
+	if comp.Name != "Anna Berg" || comp.Nation != "NOR" || comp.Bib != 7 {
+		t.Errorf("Competitor 10 = %q %q bib %d, want roster data", comp.Name, comp.Nation, comp.Bib)
+	}
+	if len(violations) != 1 {
+		t.Errorf("len(violations) = %d, want 1 for the repeated registration", len(violations))
+	}
+
+	output := out.String()
+	if !strings.Contains(output, "[09:50:00.000] Competitor(10, Anna Berg) has registered\n") {
+		t.Errorf("output should name the competitor from the roster, got %q", output)
+	}
+	if strings.Contains(output, "Warning") {
+		t.Errorf("output should keep roster warnings out of the event log, got %q", output)
+	}
+	if strings.Count(diag.String(), "Warning: Competitor(20) is not in the roster\n") != 1 {
+		t.Errorf("diagnostics should warn once about Competitor(20), got %q", diag.String())
+	}
+
+	competitors, violations, err = ProcessEvents(nil, events, cfg, Options{Roster: roster, RejectUnknown: true})
+	if err != nil {
+		t.Fatalf("ProcessEvents() error = %v", err)
+	}
+	if _, ok := competitors[20]; ok {
+		t.Error("Competitor 20 should be rejected when unknown competitors are rejected")
+	}
+	if len(violations) != 2 || violations[0].Reason != "competitor is not in the roster" {
+		t.Errorf("violations = %v, want two roster rejections", violations)
+	}
+}
+
+func TestProcessEventsMassStart(t *testing.T) {
+	start, _ := time.Parse("15:04:05.000", "10:00:00.000")
+	cfg := &models.Config{
+		Format:     models.FormatMassStart,
+		Laps:       1,
+		LapLen:     1000,
+		PenaltyLen: 150,
+		StartTime:  start,
+		StartDelta: time.Minute,
+	}
+
+	photoFinish := func(timeStr string, compID, position int) *models.Event {
+		ev := newTestEvent(timeStr, 12, compID)
+		ev.Position = position
+		return ev
+	}
+
+	events := []*models.Event{
+		newTestEvent("09:50:00.000", 1, 10),
+		newTestEvent("09:50:10.000", 1, 20),
+		newTestEvent("09:50:20.000", 1, 30),
+		newScheduledEvent("09:55:00.000", 20, "10:00:00.000"),
+		newTestEvent("09:59:00.000", 3, 10),
+		newTestEvent("09:59:00.000", 3, 20),
+		newTestEvent("10:00:00.000", 4, 10),
+		newTestEvent("10:00:00.500", 4, 20),
+		newTestEvent("10:05:00.000", 10, 10),
+		newTestEvent("10:05:00.000", 10, 20),
+		photoFinish("10:06:00.000", 20, 1),
+		photoFinish("10:06:00.000", 10, 2),
+		photoFinish("10:07:00.000", 10, 1),
+	}
+
+	var out strings.Builder
+	competitors, violations, err := ProcessEvents(&out, events, cfg, Options{})
+	if err != nil {
+		t.Fatalf("ProcessEvents() error = %v", err)
+	}
+
+	if comp := competitors[10]; comp.Status != models.StatusFinished || comp.FinishPosition != 2 {
+		t.Errorf("Competitor 10 Status = %s, FinishPosition = %d, want [Finished] and 2", comp.Status, comp.FinishPosition)
+	}
+	if comp := competitors[20]; comp.Status != models.StatusFinished || comp.FinishPosition != 1 {
+		t.Errorf("Competitor 20 Status = %s, FinishPosition = %d, want [Finished] and 1", comp.Status, comp.FinishPosition)
+	}
+	if comp := competitors[30]; comp.Status != models.StatusNotStarted || comp.DisqualifiedAt.Format("15:04:05.000") != "10:01:00.000" {
+		t.Errorf("Competitor 30 Status = %s, DisqualifiedAt = %v, want [NotStarted] at 10:01:00", comp.Status, comp.DisqualifiedAt)
+	}
+
+	if len(violations) != 1 || violations[0].Reason != "finish position is already set to 2" {
+		t.Errorf("violations = %v, want the repeated photo finish", violations)
+	}
+	if !strings.Contains(out.String(), "[10:06:00.000] Competitor(20) is placed 1 by photo finish\n") {
+		t.Errorf("output should log the photo finish, got %q", out.String())
+	}
+
+	cfg.Format = models.FormatSprint
+	_, violations, _ = ProcessEvents(nil, events, cfg, Options{})
+	if len(violations) < 2 || violations[0].EventID != 3 {
+		t.Errorf("violations = %v, want the start line without a draw rejected", violations)
+	}
+	for _, v := range violations {
+		if v.EventID == 12 && v.Reason != "event 12 is only allowed in mass-start races" {
+			t.Errorf("photo finish violation = %q, want it rejected outside mass starts", v.Reason)
+		}
+	}
+}
+
+func TestProcessEventsRelay(t *testing.T) {
+	start, _ := time.Parse("15:04:05.000", "10:00:00.000")
+	cfg := &models.Config{
+		Format:      models.FormatRelay,
+		Laps:        1,
+		LapLen:      1000,
+		PenaltyLen:  150,
+		FiringLines: 1,
+		StartTime:   start,
+		StartDelta:  time.Minute,
+	}
+
+	handOver := func(timeStr string, compID, nextID int) *models.Event {
+		ev := newTestEvent(timeStr, 13, compID)
+		ev.NextID = nextID
+		return ev
+	}
+	hit := func(timeStr string, compID, target int) *models.Event {
+		ev := newTestEvent(timeStr, 6, compID)
+		ev.Target = target
+		return ev
+	}
+
+	var events []*models.Event
+	for id := 1; id <= 6; id++ {
+		events = append(events, newTestEvent("09:50:00.000", 1, id))
+	}
+	events = append(events,
+		newTestEvent("09:59:00.000", 3, 1),
+		newTestEvent("09:59:00.000", 3, 5),
+		newTestEvent("10:00:00.000", 4, 1),
+		newTestEvent("10:00:00.000", 4, 5),
+		newTestEvent("10:00:30.000", 14, 1),
+		withRange(newTestEvent("10:01:00.000", 5, 1), 1),
+		hit("10:01:10.000", 1, 1),
+		hit("10:01:11.000", 1, 2),
+		hit("10:01:12.000", 1, 3),
+		hit("10:01:13.000", 1, 4),
+		newTestEvent("10:01:20.000", 14, 1),
+		hit("10:01:25.000", 1, 5),
+		newTestEvent("10:01:30.000", 14, 1),
+		newTestEvent("10:01:40.000", 7, 1),
+		newTestEvent("10:01:45.000", 14, 1),
+		withRange(newTestEvent("10:01:50.000", 5, 5), 1),
+		hit("10:02:00.000", 5, 1),
+		newTestEvent("10:02:10.000", 14, 5),
+		newTestEvent("10:02:20.000", 14, 5),
+		newTestEvent("10:02:30.000", 14, 5),
+		newTestEvent("10:02:40.000", 14, 5),
+		newTestEvent("10:02:50.000", 7, 5),
+		newTestEvent("10:03:00.000", 10, 1),
+		handOver("10:03:05.000", 1, 2),
+		newTestEvent("10:04:00.000", 10, 5),
+		handOver("10:04:00.000", 5, 2),
+		handOver("10:04:01.000", 5, 6),
+		newTestEvent("10:06:00.000", 11, 6),
+		newTestEvent("10:08:00.000", 10, 2),
+		handOver("10:08:00.000", 2, 3),
+		newTestEvent("10:13:00.000", 10, 3),
+		handOver("10:13:00.000", 3, 4),
+		newTestEvent("10:18:00.000", 10, 4),
+		handOver("10:18:10.000", 4, 5),
+	)
+
+	var out strings.Builder
+	competitors, violations, err := ProcessEvents(&out, events, cfg, Options{})
+	if err != nil {
+		t.Fatalf("ProcessEvents() error = %v", err)
+	}
+
+	for leg, id := range []int{1, 2, 3, 4} {
+		comp := competitors[id]
+		if comp.RelayTeam != 1 || comp.RelayLeg != leg+1 || comp.Status != models.StatusFinished {
+			t.Errorf("Competitor %d team %d leg %d %s, want team 1 leg %d [Finished]",
+				id, comp.RelayTeam, comp.RelayLeg, comp.Status, leg+1)
+		}
+	}
+	if comp := competitors[2]; comp.ActualStart.Format("15:04:05.000") != "10:03:05.000" {
+		t.Errorf("Competitor 2 ActualStart = %v, want the hand-over at 10:03:05", comp.ActualStart)
+	}
+	if comp := competitors[1]; comp.SpareRounds() != 1 || comp.Hits() != 5 {
+		t.Errorf("Competitor 1 SpareRounds = %d, Hits = %d, want 1 and 5", comp.SpareRounds(), comp.Hits())
+	}
+	if comp := competitors[6]; comp.RelayTeam != 5 || comp.Status != models.StatusNotFinished {
+		t.Errorf("Competitor 6 team %d %s, want team 5 [NotFinished]", comp.RelayTeam, comp.Status)
+	}
+
+	wantReasons := []string{
+		"spare round outside the firing range",
+		"spare round loaded with every target hit",
+		"spare round outside the firing range",
+		"all 3 spare rounds are used, a relay allows 8 rounds per 5 targets",
+		"Competitor(2) cannot take over while racing",
+		"leg 4 is the last leg of the relay",
+	}
+	if len(violations) != len(wantReasons) {
+		t.Fatalf("violations = %v, want %d", violations, len(wantReasons))
+	}
+	for i, v := range violations {
+		if v.Reason != wantReasons[i] {
+			t.Errorf("violation %d = %q, want %q", i, v.Reason, wantReasons[i])
+		}
+	}
+
+	if !strings.Contains(out.String(), "[10:03:05.000] Competitor(1) handed over to Competitor(2)\n") {
+		t.Errorf("output should log the hand-over, got %q", out.String())
+	}
+
+	cfg.Format = models.FormatSprint
+	_, violations, _ = ProcessEvents(nil, events, cfg, Options{})
+	for _, v := range violations {
+		if v.EventID == 13 && v.Reason != "event 13 is only allowed in relay races" {
+			t.Errorf("hand-over violation = %q, want it rejected outside relays", v.Reason)
+		}
+	}
+}
+
+func TestProcessEventsRelayStartDeadline(t *testing.T) {
+	start, _ := time.Parse("15:04:05.000", "10:00:00.000")
+	cfg := &models.Config{
+		Format:      models.FormatRelay,
+		Laps:        1,
+		LapLen:      1000,
+		PenaltyLen:  150,
+		FiringLines: 1,
+		StartTime:   start,
+		StartDelta:  time.Minute,
+	}
+
+	handOver := newTestEvent("10:05:00.000", 13, 1)
+	handOver.NextID = 2
+
+	events := []*models.Event{
+		newTestEvent("09:50:00.000", 1, 1),
+		newTestEvent("09:50:00.000", 1, 2),
+		newTestEvent("09:50:00.000", 1, 3),
+		newTestEvent("09:59:00.000", 3, 1),
+		newTestEvent("09:59:00.000", 3, 3),
+		newTestEvent("10:00:00.000", 4, 1),
+		newTestEvent("10:05:00.000", 10, 1),
+		handOver,
+	}
+
+	competitors, violations, err := ProcessEvents(nil, events, cfg, Options{})
+	if err != nil {
+		t.Fatalf("ProcessEvents() error = %v", err)
+	}
+	if len(violations) != 0 {
+		t.Fatalf("violations = %v, want none", violations)
+	}
+
+	if comp := competitors[1]; !comp.ScheduledStart.Equal(start) {
+		t.Errorf("Competitor 1 ScheduledStart = %v, want the race start", comp.ScheduledStart)
+	}
+	if comp := competitors[2]; !comp.DisqualifiedAt.IsZero() || comp.RelayLeg != 2 {
+		t.Errorf("Competitor 2 DisqualifiedAt = %v on leg %d, want leg 2 without a start deadline", comp.DisqualifiedAt, comp.RelayLeg)
+	}
+	if comp := competitors[3]; comp.Status != models.StatusNotStarted || comp.DisqualifiedAt.Format("15:04:05.000") != "10:01:00.000" {
+		t.Errorf("Competitor 3 Status = %s, DisqualifiedAt = %v, want [NotStarted] at 10:01:00", comp.Status, comp.DisqualifiedAt)
+	}
+}
+
+func TestProcessEventsStartOutsideRelay(t *testing.T) {
+	cfg := &models.Config{Format: models.FormatSprint, Laps: 3, LapLen: 1000, PenaltyLen: 150, FiringLines: 2}
+
+	events := []*models.Event{
+		newTestEvent("09:50:00.000", 1, 10),
+		newScheduledEvent("09:55:00.000", 10, "10:00:00.000"),
+		newTestEvent("09:59:00.000", 3, 10),
+		newTestEvent("10:00:00.000", 4, 10),
+	}
+
+	competitors, _, err := ProcessEvents(nil, events, cfg, Options{})
+	if err != nil {
+		t.Fatalf("ProcessEvents() error = %v", err)
+	}
+	if comp := competitors[10]; comp.ActualStart.IsZero() || comp.RelayTeam != 0 || comp.RelayLeg != 0 {
+		t.Errorf("Competitor 10 started %v in team %d leg %d, want a start without a relay team",
+			comp.ActualStart, comp.RelayTeam, comp.RelayLeg)
+	}
+}
+
+func TestProcessEventsTargets(t *testing.T) {
+	cfg := &models.Config{Laps: 1, LapLen: 1000, PenaltyLen: 150, FiringLines: 1}
+
+	hit := func(timeStr string, target, line int) *models.Event {
+		ev := withTarget(newTestEvent(timeStr, 6, 10), target)
+		ev.Line = line
+		return ev
+	}
+
+	events := []*models.Event{
+		newTestEvent("09:50:00.000", 1, 10),
+		newScheduledEvent("09:55:00.000", 10, "10:00:00.000"),
+		newTestEvent("09:59:00.000", 3, 10),
+		newTestEvent("10:00:00.000", 4, 10),
+		withRange(newTestEvent("10:01:00.000", 5, 10), 1),
+		hit("10:01:01.000", 1, 6),
+		hit("10:01:02.000", 1, 7),
+		hit("10:01:03.000", 6, 8),
+		hit("10:01:04.000", 0, 9),
+		hit("10:01:05.000", -1, 10),
+		hit("10:01:06.000", 5, 11),
+		newTestEvent("10:01:10.000", 7, 10),
+	}
+
+	competitors, _, violations, err := processEvents(nil, events, cfg, Options{})
+	if err != nil {
+		t.Fatalf("processEvents() error = %v", err)
+	}
+
+	want := []struct {
+		line   int
+		reason string
+	}{
+		{7, "target 1 is already hit"},
+		{8, "target 6 is out of range 1-5"},
+		{9, "target hit without a target number"},
+		{10, "target -1 is out of range 1-5"},
+	}
+	if len(violations) != len(want) {
+		t.Fatalf("violations = %v, want %d", violations, len(want))
+	}
+	for i, v := range violations {
+		if v.Line != want[i].line || v.Reason != want[i].reason {
+			t.Errorf("violation %d = line %d %q, want line %d %q", i, v.Line, v.Reason, want[i].line, want[i].reason)
+		}
+	}
+
+	if visit := competitors[10].RangeVisits[0]; visit.Hits() != 2 || visit.Misses() != 3 {
+		t.Errorf("visit Hits = %d, Misses = %d, want 2 and 3", visit.Hits(), visit.Misses())
+	}
+}
+
+func TestProcessEventsFiringRanges(t *testing.T) {
+	cfg := &models.Config{Laps: 1, LapLen: 1000, PenaltyLen: 150, FiringLines: 3}
+
+	enter := func(timeStr string, compID, firingRange, line int) *models.Event {
+		ev := withRange(newTestEvent(timeStr, 5, compID), firingRange)
+		ev.Line = line
+		return ev
+	}
+
+	events := []*models.Event{
+		newTestEvent("09:50:00.000", 1, 10),
+		newScheduledEvent("09:55:00.000", 10, "10:00:00.000"),
+		newTestEvent("09:59:00.000", 3, 10),
+		newTestEvent("10:00:00.000", 4, 10),
+		enter("10:01:00.000", 10, 4, 5),
+		enter("10:01:01.000", 10, 0, 6),
+		enter("10:01:02.000", 10, 1, 7),
+		newTestEvent("10:01:30.000", 7, 10),
+		enter("10:02:00.000", 10, 1, 9),
+		enter("10:02:01.000", 10, 3, 10),
+		newTestEvent("10:02:30.000", 7, 10),
+		newTestEvent("10:05:00.000", 10, 10),
+
+		newTestEvent("09:50:00.000", 1, 20),
+		newScheduledEvent("09:55:00.000", 20, "10:00:00.000"),
+		newTestEvent("09:59:00.000", 3, 20),
+		newTestEvent("10:00:00.000", 4, 20),
+		enter("10:01:00.000", 20, 1, 17),
+		newTestEvent("10:01:30.000", 7, 20),
+		enter("10:02:00.000", 20, 2, 19),
+		newTestEvent("10:02:30.000", 7, 20),
+		enter("10:03:00.000", 20, 3, 21),
+		newTestEvent("10:03:30.000", 7, 20),
+		enter("10:04:00.000", 20, 2, 23),
+	}
+
+	competitors, _, violations, err := processEvents(nil, events, cfg, Options{})
+	if err != nil {
+		t.Fatalf("processEvents() error = %v", err)
+	}
+
+	want := []struct {
+		line   int
+		reason string
+	}{
+		{5, "firing range 4 is out of range 1-3"},
+		{6, "firing range entered without a range number"},
+		{9, "firing range 1 is already visited"},
+		{23, "all 3 firing ranges are already visited"},
+	}
+	if len(violations) != len(want) {
+		t.Fatalf("violations = %v, want %d", violations, len(want))
+	}
+	for i, v := range violations {
+		if v.Line != want[i].line || v.Reason != want[i].reason {
+			t.Errorf("violation %d = line %d %q, want line %d %q", i, v.Line, v.Reason, want[i].line, want[i].reason)
+		}
+	}
+
+	visits := competitors[10].RangeVisits
+	if len(visits) != 3 {
+		t.Fatalf("RangeVisits = %d, want the skipped range added at the finish", len(visits))
+	}
+	for i, visit := range visits {
+		if visit.FiringRange != i+1 || visit.Misses() != 5 {
+			t.Errorf("RangeVisits[%d] = range %d with %d misses, want range %d with 5",
+				i, visit.FiringRange, visit.Misses(), i+1)
+		}
+	}
+	if !visits[1].Enter.IsZero() {
+		t.Errorf("skipped range Enter = %v, want zero", visits[1].Enter)
+	}
+}
+
+func TestProcessEventsTimeOrder(t *testing.T) {
+	cfg := &models.Config{Laps: 2, LapLen: 1000, PenaltyLen: 150}
+
+	withLine := func(ev *models.Event, line int) *models.Event {
+		ev.Line = line
+		return ev
+	}
+
+	events := []*models.Event{
+		withLine(newTestEvent("09:50:00.000", 1, 10), 1),
+		withLine(newScheduledEvent("09:55:00.000", 10, "10:00:00.000"), 2),
+		withLine(newTestEvent("09:59:00.000", 3, 10), 3),
+		withLine(newTestEvent("10:00:00.000", 4, 10), 4),
+		withLine(newTestEvent("10:05:00.000", 10, 10), 5),
+		withLine(newTestEvent("10:04:00.000", 10, 10), 6),
+		withLine(newTestEvent("10:04:30.000", 1, 20), 7),
+		withLine(newTestEvent("10:09:00.000", 10, 10), 8),
+	}
+
+	competitors, violations, err := ProcessEvents(nil, events, cfg, Options{})
+	if err != nil {
+		t.Fatalf("ProcessEvents() error = %v", err)
+	}
+
+	if len(violations) != 1 || violations[0].Line != 6 ||
+		violations[0].Reason != "event is earlier than the previous event at 10:05:00.000" {
+		t.Fatalf("violations = %v, want the lap on line 6 rejected", violations)
+	}
+
+	comp := competitors[10]
+	want := []time.Duration{5 * time.Minute, 4 * time.Minute}
+	if len(comp.LapTimes) != 2 || comp.LapTimes[0] != want[0] || comp.LapTimes[1] != want[1] {
+		t.Errorf("LapTimes = %v, want %v", comp.LapTimes, want)
+	}
+	if _, ok := competitors[20]; !ok {
+		t.Error("an earlier event of another competitor should still be accepted")
+	}
 }
@@ -0,0 +1,55 @@
+package eventprocess
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"biathlon_events_parser/internal/models"
+)
+
+func checkPenaltyLaps(comp *models.Competitor, cfg *models.Config, at time.Time, logf func(time.Time, string, ...any)) {
+	speed := comp.CourseSpeed
+
+	for i := range comp.RangeVisits {
+		visit := &comp.RangeVisits[i]
+		visit.PenaltyLaps = estimatePenaltyLaps(visit.PenaltyTime(), speed, cfg.PenaltyLen)
+
+		if required := visit.Misses(); visit.PenaltyLaps < required {
+			logf(at, "%s served %d of %d penalty laps at firing range(%d)",
+				competitorLabel(comp), visit.PenaltyLaps, required, visit.FiringRange)
+		}
+	}
+}
+
+// addSkippedRanges records a visit without hits for every firing range the
+// competitor never entered, so each one counts as five misses.
+func addSkippedRanges(comp *models.Competitor, cfg *models.Config, at time.Time, logf func(time.Time, string, ...any)) {
+	visited := make(map[int]bool, len(comp.RangeVisits))
+	for _, visit := range comp.RangeVisits {
+		visited[visit.FiringRange] = true
+	}
+
+	skipped := false
+	for n := 1; n <= cfg.FiringLines; n++ {
+		if visited[n] {
+			continue
+		}
+		comp.RangeVisits = append(comp.RangeVisits, models.RangeVisit{FiringRange: n})
+		logf(at, "%s skipped the firing range(%d)", competitorLabel(comp), n)
+		skipped = true
+	}
+
+	if skipped {
+		sort.SliceStable(comp.RangeVisits, func(i, j int) bool {
+			return comp.RangeVisits[i].FiringRange < comp.RangeVisits[j].FiringRange
+		})
+	}
+}
+
+func estimatePenaltyLaps(penalty time.Duration, speed float64, penaltyLen int) int {
+	if penalty <= 0 || speed <= 0 || penaltyLen <= 0 {
+		return 0
+	}
+	return int(math.Round(penalty.Seconds() * speed / float64(penaltyLen)))
+}
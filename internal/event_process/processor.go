@@ -0,0 +1,403 @@
+package eventprocess
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	"biathlon_events_parser/internal/models"
+	"biathlon_events_parser/internal/rules"
+)
+
+var ErrFinalized = errors.New("race is already finalized")
+
+type LogEntry struct {
+	Time    time.Time
+	Message string
+}
+
+type Processor struct {
+	cfg         *models.Config
+	rules       rules.Rules
+	out         io.Writer
+	diag        io.Writer
+	competitors map[int]*models.Competitor
+	states      map[int]state
+	lastEvent   map[int]time.Time
+	awaiting    map[int]time.Time
+	log         []LogEntry
+	violations  []*Violation
+	clock       time.Time
+	finalized   bool
+
+	roster        models.Roster
+	rejectUnknown bool
+	warned        map[int]bool
+}
+
+func NewProcessor(cfg *models.Config, out io.Writer) *Processor {
+	if out == nil {
+		out = io.Discard
+	}
+
+	return &Processor{
+		cfg:         cfg,
+		rules:       rules.For(cfg),
+		out:         out,
+		diag:        io.Discard,
+		competitors: make(map[int]*models.Competitor),
+		states:      make(map[int]state),
+		lastEvent:   make(map[int]time.Time),
+		awaiting:    make(map[int]time.Time),
+		warned:      make(map[int]bool),
+	}
+}
+
+// SetDiagnostics sets the writer for rejected events and roster warnings,
+// which are kept out of the event log.
+func (p *Processor) SetDiagnostics(w io.Writer) {
+	if w == nil {
+		w = io.Discard
+	}
+	p.diag = w
+}
+
+func (p *Processor) SetRoster(roster models.Roster, rejectUnknown bool) {
+	p.roster = roster
+	p.rejectUnknown = rejectUnknown
+}
+
+func (p *Processor) Apply(ev *models.Event) error {
+	if p.finalized {
+		return ErrFinalized
+	}
+
+	if ev.Time.After(p.clock) {
+		p.clock = ev.Time
+	}
+	p.disqualifyBefore(ev.Time)
+
+	id := ev.CompetitorID
+	err := p.checkRoster(id)
+	if err == nil {
+		err = p.checkOrder(id, ev.Time)
+	}
+	if err == nil {
+		err = checkEvent(p.competitors[id], ev, p.cfg, p.rules)
+	}
+	current := p.states[id]
+	if current == stateRegistered && ev.EventID == 3 && p.rules.DrawOptional() {
+		// competitors without a draw start on the race start
+		current = stateScheduled
+	}
+	next := current
+	if err == nil {
+		next, err = nextState(current, ev.EventID)
+	}
+	if err == nil && ev.EventID == 13 {
+		err = p.checkHandOver(ev)
+	}
+	if err != nil {
+		v := &Violation{
+			Line:         ev.Line,
+			Time:         ev.Time,
+			EventID:      ev.EventID,
+			CompetitorID: id,
+			Reason:       err.Error(),
+		}
+		p.violations = append(p.violations, v)
+		fmt.Fprintf(p.diag, "Rejected event at %v\n", v)
+		return v
+	}
+	p.states[id] = next
+	p.lastEvent[id] = ev.Time
+
+	if _, exists := p.competitors[id]; !exists {
+		comp := &models.Competitor{ID: id}
+		if athlete, ok := p.roster[id]; ok {
+			comp.SetAthlete(athlete)
+		}
+		p.competitors[id] = comp
+	}
+	p.apply(p.competitors[id], ev)
+
+	return nil
+}
+
+func (p *Processor) apply(comp *models.Competitor, ev *models.Event) {
+	cfg := p.cfg
+	id := comp.ID
+	name := competitorLabel(comp)
+
+	switch ev.EventID {
+	case 1:
+		if p.rules.SharedStart() {
+			comp.ScheduledStart = cfg.StartTime
+			p.awaiting[id] = startDeadline(comp, cfg)
+		}
+		p.logf(ev.Time, "%s has registered", name)
+	case 2:
+		comp.ScheduledStart = ev.StartTime
+		p.awaiting[id] = startDeadline(comp, cfg)
+		p.logf(ev.Time, "Scheduled start time for %s is %s (by draw)",
+			name, ev.StartTime.Format("15:04:05.000"))
+	case 3:
+		// a relay only learns its first legs when they line up for the start
+		if p.rules.DrawOptional() && comp.ScheduledStart.IsZero() {
+			comp.ScheduledStart = cfg.StartTime
+			p.awaiting[id] = startDeadline(comp, cfg)
+		}
+		p.logf(ev.Time, "%s is on the start line", name)
+	case 4:
+		comp.ActualStart = ev.Time
+		comp.LastLapEnd = ev.Time
+		delete(p.awaiting, id)
+		if !comp.DisqualifiedAt.IsZero() {
+			comp.Status = models.StatusDisqualified
+		}
+		if p.rules.Legs() > 0 {
+			comp.RelayTeam = id
+			comp.RelayLeg = 1
+		}
+		p.logf(ev.Time, "%s has started", name)
+	case 5:
+		comp.RangeVisits = append(comp.RangeVisits, models.RangeVisit{
+			FiringRange: ev.FiringRange,
+			Enter:       ev.Time,
+		})
+		p.logf(ev.Time, "%s entered the firing range(%d)", name, ev.FiringRange)
+	case 6:
+		comp.RangeVisits[len(comp.RangeVisits)-1].Hit(ev.Target)
+		p.logf(ev.Time, "Target(%d) was hit by %s", ev.Target, name)
+	case 7:
+		visit := &comp.RangeVisits[len(comp.RangeVisits)-1]
+		visit.Leave = ev.Time
+		comp.RangeTimes = append(comp.RangeTimes, visit.Leave.Sub(visit.Enter))
+		p.logf(ev.Time, "%s left the firing range", name)
+	case 8:
+		comp.RangeVisits[len(comp.RangeVisits)-1].PenaltyEnter = ev.Time
+		p.logf(ev.Time, "%s entered the penalty lap", name)
+	case 9:
+		visit := &comp.RangeVisits[len(comp.RangeVisits)-1]
+		visit.PenaltyLeave = ev.Time
+		comp.PenaltyTimes = append(comp.PenaltyTimes, visit.PenaltyTime())
+		comp.PenaltyTime += visit.PenaltyTime()
+		if comp.PenaltyTime > 0 {
+			comp.PenaltySpeed = float64(cfg.PenaltyLen) / comp.PenaltyTime.Seconds()
+		}
+
+		p.logf(ev.Time, "%s left the penalty lap", name)
+	case 10:
+		lapTime := ev.Time.Sub(comp.LastLapEnd)
+		comp.LapTimes = append(comp.LapTimes, lapTime)
+		var speed float64
+		if lapTime > 0 {
+			speed = float64(cfg.LapLen) / lapTime.Seconds()
+		}
+		comp.LapSpeeds = append(comp.LapSpeeds, speed)
+		comp.LastLapEnd = ev.Time
+		updateCourseTime(comp, cfg)
+
+		p.logf(ev.Time, "%s finished a lap", name)
+
+		if len(comp.LapTimes) == cfg.Laps {
+			p.states[id] = stateFinished
+			if comp.Status == "" {
+				comp.Status = models.StatusFinished
+				p.logf(ev.Time, "%s has finished", name)
+				addSkippedRanges(comp, cfg, ev.Time, p.logf)
+				if p.rules.PenaltyLoops() {
+					checkPenaltyLaps(comp, cfg, ev.Time, p.logf)
+				}
+			}
+		}
+	case 11:
+		delete(p.awaiting, id)
+		if comp.Status == "" {
+			comp.Status = models.StatusNotFinished
+		}
+		comp.Comment = ev.Comment
+		p.logf(ev.Time, "%s cannot continue: %s", name, ev.Comment)
+	case 12:
+		comp.FinishPosition = ev.Position
+		p.logf(ev.Time, "%s is placed %d by photo finish", name, ev.Position)
+	case 13:
+		next := p.competitors[ev.NextID]
+		comp.HandOverAt = ev.Time
+		next.RelayTeam = comp.RelayTeam
+		next.RelayLeg = comp.RelayLeg + 1
+		next.ActualStart = ev.Time
+		next.LastLapEnd = ev.Time
+		p.states[next.ID] = stateRacing
+		p.lastEvent[next.ID] = ev.Time
+		delete(p.awaiting, next.ID)
+		p.logf(ev.Time, "%s handed over to %s", name, competitorLabel(next))
+	case 14:
+		comp.RangeVisits[len(comp.RangeVisits)-1].SpareRounds++
+		p.logf(ev.Time, "%s loaded a spare round", name)
+	}
+}
+
+func (p *Processor) checkHandOver(ev *models.Event) error {
+	next, ok := p.competitors[ev.NextID]
+	if !ok {
+		return fmt.Errorf("Competitor(%d) is not registered", ev.NextID)
+	}
+	if err := p.checkOrder(next.ID, ev.Time); err != nil {
+		return fmt.Errorf("%s: %w", competitorLabel(next), err)
+	}
+
+	switch p.states[next.ID] {
+	case stateRegistered, stateScheduled, stateOnStartLine:
+		return nil
+	default:
+		return fmt.Errorf("%s cannot take over while %s", competitorLabel(next), p.states[next.ID])
+	}
+}
+
+// checkOrder rejects events that go back in time for a competitor, which
+// would give negative lap times.
+func (p *Processor) checkOrder(id int, t time.Time) error {
+	last, ok := p.lastEvent[id]
+	if ok && t.Before(last) {
+		return fmt.Errorf("event is earlier than the previous event at %s", last.Format("15:04:05.000"))
+	}
+	return nil
+}
+
+func (p *Processor) checkRoster(id int) error {
+	if p.roster == nil {
+		return nil
+	}
+	if _, ok := p.roster[id]; ok {
+		return nil
+	}
+	if p.rejectUnknown {
+		return errors.New("competitor is not in the roster")
+	}
+	if !p.warned[id] {
+		p.warned[id] = true
+		fmt.Fprintf(p.diag, "Warning: Competitor(%d) is not in the roster\n", id)
+	}
+	return nil
+}
+
+func (p *Processor) Competitor(id int) (*models.Competitor, bool) {
+	comp, ok := p.competitors[id]
+	return comp, ok
+}
+
+func (p *Processor) Competitors() map[int]*models.Competitor {
+	return p.competitors
+}
+
+func (p *Processor) Log() []LogEntry {
+	return p.log
+}
+
+func (p *Processor) Violations() []*Violation {
+	return p.violations
+}
+
+func (p *Processor) Finalize() map[int]*models.Competitor {
+	if p.finalized {
+		return p.competitors
+	}
+	p.finalized = true
+
+	for _, id := range p.sortedIDs() {
+		comp := p.competitors[id]
+		if comp.Status != "" || comp.ActualStart.IsZero() {
+			continue
+		}
+
+		comp.Status = models.StatusNotFinished
+		comp.Comment = fmt.Sprintf("completed %d of %d laps", len(comp.LapTimes), p.cfg.Laps)
+		p.logf(p.clock, "%s %s", competitorLabel(comp), comp.Comment)
+	}
+
+	p.disqualifyBefore(time.Time{})
+
+	for _, comp := range p.competitors {
+		if comp.Status == "" {
+			comp.Status = models.StatusNotStarted
+		}
+	}
+
+	return p.competitors
+}
+
+// disqualifyBefore disqualifies every scheduled competitor who has not
+// started by the time the race clock reaches now. A zero now flushes all
+// of them, which is what Finalize wants.
+func (p *Processor) disqualifyBefore(now time.Time) {
+	var due []int
+	for id, deadline := range p.awaiting {
+		if now.IsZero() || deadline.Before(now) {
+			due = append(due, id)
+		}
+	}
+
+	sort.Slice(due, func(i, j int) bool {
+		di, dj := p.awaiting[due[i]], p.awaiting[due[j]]
+		if !di.Equal(dj) {
+			return di.Before(dj)
+		}
+		return due[i] < due[j]
+	})
+
+	for _, id := range due {
+		comp := p.competitors[id]
+		comp.Status = models.StatusNotStarted
+		comp.DisqualifiedAt = p.awaiting[id]
+		delete(p.awaiting, id)
+		p.logf(comp.DisqualifiedAt, "%s is disqualified", competitorLabel(comp))
+	}
+}
+
+func (p *Processor) logf(t time.Time, format string, args ...any) {
+	args = append([]any{t.Format("15:04:05.000")}, args...)
+	entry := LogEntry{Time: t, Message: fmt.Sprintf("[%s] "+format, args...)}
+	p.log = append(p.log, entry)
+	fmt.Fprintln(p.out, entry.Message)
+}
+
+func competitorLabel(comp *models.Competitor) string {
+	if comp.Name == "" {
+		return fmt.Sprintf("Competitor(%d)", comp.ID)
+	}
+	return fmt.Sprintf("Competitor(%d, %s)", comp.ID, comp.Name)
+}
+
+func (p *Processor) sortedIDs() []int {
+	ids := make([]int, 0, len(p.competitors))
+	for id := range p.competitors {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+	return ids
+}
+
+func startDeadline(comp *models.Competitor, cfg *models.Config) time.Time {
+	if comp.ScheduledStart.IsZero() {
+		return time.Time{}
+	}
+	return comp.ScheduledStart.Add(cfg.StartDelta)
+}
+
+func updateCourseTime(comp *models.Competitor, cfg *models.Config) {
+	comp.CourseTime = sumDurations(comp.LapTimes) - sumDurations(comp.RangeTimes) - sumDurations(comp.PenaltyTimes)
+	if comp.CourseTime > 0 {
+		comp.CourseSpeed = float64(cfg.LapLen*len(comp.LapTimes)) / comp.CourseTime.Seconds()
+	}
+}
+
+func sumDurations(ds []time.Duration) time.Duration {
+	var total time.Duration
+	for _, d := range ds {
+		total += d
+	}
+	return total
+}
@@ -0,0 +1,170 @@
+package eventprocess
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"biathlon_events_parser/internal/models"
+	"biathlon_events_parser/internal/rules"
+)
+
+type state int
+
+const (
+	stateUnregistered state = iota
+	stateRegistered
+	stateScheduled
+	stateOnStartLine
+	stateRacing
+	stateOnRange
+	statePenalty
+	stateFinished
+	stateNotFinished
+)
+
+var stateNames = map[state]string{
+	stateUnregistered: "not registered",
+	stateRegistered:   "registered",
+	stateScheduled:    "scheduled",
+	stateOnStartLine:  "on the start line",
+	stateRacing:       "racing",
+	stateOnRange:      "on the firing range",
+	statePenalty:      "on the penalty lap",
+	stateFinished:     "finished",
+	stateNotFinished:  "not finished",
+}
+
+func (s state) String() string {
+	return stateNames[s]
+}
+
+type transition struct {
+	from []state
+	to   state
+}
+
+var transitions = map[int]transition{
+	1:  {from: []state{stateUnregistered}, to: stateRegistered},
+	2:  {from: []state{stateRegistered}, to: stateScheduled},
+	3:  {from: []state{stateScheduled}, to: stateOnStartLine},
+	4:  {from: []state{stateOnStartLine}, to: stateRacing},
+	5:  {from: []state{stateRacing}, to: stateOnRange},
+	6:  {from: []state{stateOnRange}, to: stateOnRange},
+	7:  {from: []state{stateOnRange}, to: stateRacing},
+	8:  {from: []state{stateRacing}, to: statePenalty},
+	9:  {from: []state{statePenalty}, to: stateRacing},
+	10: {from: []state{stateRacing}, to: stateRacing},
+	11: {
+		from: []state{stateRegistered, stateScheduled, stateOnStartLine, stateRacing, stateOnRange, statePenalty},
+		to:   stateNotFinished,
+	},
+	12: {from: []state{stateFinished}, to: stateFinished},
+	13: {from: []state{stateFinished}, to: stateFinished},
+	14: {from: []state{stateOnRange}, to: stateOnRange},
+}
+
+func nextState(current state, eventID int) (state, error) {
+	tr, ok := transitions[eventID]
+	if !ok {
+		return current, fmt.Errorf("unknown event ID %d", eventID)
+	}
+
+	for _, from := range tr.from {
+		if from == current {
+			return tr.to, nil
+		}
+	}
+
+	return current, fmt.Errorf("event %d is not allowed while competitor is %s", eventID, current)
+}
+
+func checkEvent(comp *models.Competitor, ev *models.Event, cfg *models.Config, r rules.Rules) error {
+	if (ev.EventID == 8 || ev.EventID == 9) && !r.PenaltyLoops() {
+		return fmt.Errorf("event %d is not allowed, %s races have no penalty loops", ev.EventID, r.Format())
+	}
+
+	if ev.EventID == 12 && !r.SharedStart() {
+		return errors.New("event 12 is only allowed in mass-start races")
+	}
+
+	if (ev.EventID == 13 || ev.EventID == 14) && r.Legs() == 0 {
+		return fmt.Errorf("event %d is only allowed in relay races", ev.EventID)
+	}
+
+	if comp == nil {
+		return nil
+	}
+
+	switch ev.EventID {
+	case 5:
+		switch {
+		case len(comp.RangeVisits) >= cfg.FiringLines:
+			return fmt.Errorf("all %d firing ranges are already visited", cfg.FiringLines)
+		case ev.FiringRange == 0:
+			return errors.New("firing range entered without a range number")
+		case ev.FiringRange < 0 || ev.FiringRange > cfg.FiringLines:
+			return fmt.Errorf("firing range %d is out of range 1-%d", ev.FiringRange, cfg.FiringLines)
+		}
+		for _, visit := range comp.RangeVisits {
+			if visit.FiringRange == ev.FiringRange {
+				return fmt.Errorf("firing range %d is already visited", ev.FiringRange)
+			}
+		}
+	case 6:
+		switch {
+		case ev.Target == 0:
+			return errors.New("target hit without a target number")
+		case ev.Target < 0 || ev.Target > models.ShotsPerVisit:
+			return fmt.Errorf("target %d is out of range 1-%d", ev.Target, models.ShotsPerVisit)
+		}
+		if n := len(comp.RangeVisits); n > 0 && comp.RangeVisits[n-1].IsHit(ev.Target) {
+			return fmt.Errorf("target %d is already hit", ev.Target)
+		}
+	case 8:
+		if len(comp.RangeVisits) == 0 || !comp.RangeVisits[len(comp.RangeVisits)-1].PenaltyEnter.IsZero() {
+			return errors.New("penalty lap without a preceding firing range visit")
+		}
+	case 10:
+		if len(comp.LapTimes) >= cfg.Laps {
+			return fmt.Errorf("extra lap %d, the race has %d laps", len(comp.LapTimes)+1, cfg.Laps)
+		}
+	case 12:
+		if comp.FinishPosition != 0 {
+			return fmt.Errorf("finish position is already set to %d", comp.FinishPosition)
+		}
+	case 13:
+		if !comp.HandOverAt.IsZero() {
+			return errors.New("competitor has already handed over")
+		}
+		if comp.RelayLeg >= r.Legs() {
+			return fmt.Errorf("leg %d is the last leg of the relay", comp.RelayLeg)
+		}
+	case 14:
+		if len(comp.RangeVisits) == 0 || !comp.RangeVisits[len(comp.RangeVisits)-1].Leave.IsZero() {
+			return errors.New("spare round outside the firing range")
+		}
+		visit := comp.RangeVisits[len(comp.RangeVisits)-1]
+		switch {
+		case visit.Misses() == 0:
+			return errors.New("spare round loaded with every target hit")
+		case visit.SpareRounds >= r.SpareRounds():
+			return fmt.Errorf("all %d spare rounds are used, a relay allows %d rounds per %d targets",
+				r.SpareRounds(), rules.RelayRounds, models.ShotsPerVisit)
+		}
+	}
+	return nil
+}
+
+type Violation struct {
+	Line         int
+	Time         time.Time
+	EventID      int
+	CompetitorID int
+	Reason       string
+}
+
+func (v *Violation) Error() string {
+	return fmt.Sprintf("line %d: [%s] Competitor(%d): %s",
+		v.Line, v.Time.Format("15:04:05.000"), v.CompetitorID, v.Reason)
+}
@@ -0,0 +1,11 @@
+package models
+
+type Athlete struct {
+	ID     int    `json:"id"`
+	Name   string `json:"name"`
+	Nation string `json:"nation"`
+	Team   string `json:"team"`
+	Bib    int    `json:"bib"`
+}
+
+type Roster map[int]Athlete
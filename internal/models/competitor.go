@@ -2,17 +2,86 @@ package models
 
 import "time"
 
+const (
+	StatusFinished     = "[Finished]"
+	StatusNotFinished  = "[NotFinished]"
+	StatusNotStarted   = "[NotStarted]"
+	StatusDisqualified = "[Disqualified]"
+)
+
 type Competitor struct {
 	ID             int
-	ScheduledStart time.Time   
-	ActualStart    time.Time   
-	LastLapEnd     time.Time   
-	Hits           int         
-	ShotsHit       int         
-	PenaltyTime    time.Duration 
-	PenaltySpeed   float64      
-	LapTimes       []time.Duration 
-	LapSpeeds      []float64       
-	Status         string       
-	Comment        string 
-}
\ No newline at end of file
+	Name           string
+	Nation         string
+	Team           string
+	Bib            int
+	ScheduledStart time.Time
+	ActualStart    time.Time
+	DisqualifiedAt time.Time
+	LastLapEnd     time.Time
+	FinishPosition int
+	RelayTeam      int
+	RelayLeg       int
+	HandOverAt     time.Time
+	RangeVisits    []RangeVisit
+	PenaltyTime    time.Duration
+	PenaltySpeed   float64
+	LapTimes       []time.Duration
+	LapSpeeds      []float64
+	RangeTimes     []time.Duration
+	PenaltyTimes   []time.Duration
+	CourseTime     time.Duration
+	CourseSpeed    float64
+	Status         string
+	Comment        string
+}
+
+func (c *Competitor) Hits() int {
+	hits := 0
+	for _, v := range c.RangeVisits {
+		hits += v.Hits()
+	}
+	return hits
+}
+
+func (c *Competitor) Misses() int {
+	misses := 0
+	for _, v := range c.RangeVisits {
+		misses += v.Misses()
+	}
+	return misses
+}
+
+func (c *Competitor) SpareRounds() int {
+	spares := 0
+	for _, v := range c.RangeVisits {
+		spares += v.SpareRounds
+	}
+	return spares
+}
+
+func (c *Competitor) PenaltyLaps() (served, required int) {
+	for _, v := range c.RangeVisits {
+		served += v.PenaltyLaps
+		required += v.Misses()
+	}
+	return served, required
+}
+
+func (c *Competitor) SetAthlete(a Athlete) {
+	c.Name = a.Name
+	c.Nation = a.Nation
+	c.Team = a.Team
+	c.Bib = a.Bib
+}
+
+func (c *Competitor) DisplayName() string {
+	switch {
+	case c.Name == "":
+		return ""
+	case c.Nation == "":
+		return c.Name
+	default:
+		return c.Name + " (" + c.Nation + ")"
+	}
+}
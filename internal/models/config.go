@@ -2,11 +2,24 @@ package models
 
 import "time"
 
+type RaceFormat string
+
+const (
+	FormatSprint     RaceFormat = "sprint"
+	FormatIndividual RaceFormat = "individual"
+	FormatPursuit    RaceFormat = "pursuit"
+	FormatMassStart  RaceFormat = "mass-start"
+	FormatRelay      RaceFormat = "relay"
+)
+
 type Config struct {
-	Laps        int           `json:"laps"`
-	LapLen      int           `json:"lapLen"`
-	PenaltyLen  int           `json:"penaltyLen"`
-	FiringLines int           `json:"firingLines"`
-	StartTime   time.Time     `json:"start"`
-	StartDelta  time.Duration `json:"startDelta"`
+	Format      RaceFormat
+	Laps        int
+	LapLen      int
+	PenaltyLen  int
+	FiringLines int
+	Date        time.Time
+	StartTime   time.Time
+	StartDelta  time.Duration
+	MissPenalty time.Duration
 }
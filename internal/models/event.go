@@ -9,5 +9,8 @@ type Event struct {
 	StartTime    time.Time
 	FiringRange  int
 	Target       int
+	Position     int
+	NextID       int
 	Comment      string
+	Line         int
 }
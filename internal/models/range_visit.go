@@ -0,0 +1,46 @@
+package models
+
+import "time"
+
+const ShotsPerVisit = 5
+
+type RangeVisit struct {
+	FiringRange  int
+	Enter        time.Time
+	Leave        time.Time
+	Targets      []int
+	SpareRounds  int
+	PenaltyEnter time.Time
+	PenaltyLeave time.Time
+	PenaltyLaps  int
+}
+
+func (v *RangeVisit) Hit(target int) {
+	if !v.IsHit(target) {
+		v.Targets = append(v.Targets, target)
+	}
+}
+
+func (v *RangeVisit) IsHit(target int) bool {
+	for _, t := range v.Targets {
+		if t == target {
+			return true
+		}
+	}
+	return false
+}
+
+func (v *RangeVisit) Hits() int {
+	return len(v.Targets)
+}
+
+func (v *RangeVisit) Misses() int {
+	return ShotsPerVisit - v.Hits()
+}
+
+func (v *RangeVisit) PenaltyTime() time.Duration {
+	if v.PenaltyEnter.IsZero() || v.PenaltyLeave.IsZero() {
+		return 0
+	}
+	return v.PenaltyLeave.Sub(v.PenaltyEnter)
+}
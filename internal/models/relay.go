@@ -0,0 +1,53 @@
+package models
+
+import "time"
+
+const RelayLegs = 4
+
+type RelayLeg struct {
+	Number     int
+	Competitor *Competitor
+	Split      time.Duration
+}
+
+// RelayTeam is known by the ID of the competitor who runs its first leg;
+// every later leg joins the team through a hand-over.
+type RelayTeam struct {
+	ID     int
+	Name   string
+	Legs   []RelayLeg
+	Status string
+}
+
+func (t *RelayTeam) TotalTime() time.Duration {
+	var total time.Duration
+	for _, leg := range t.Legs {
+		total += leg.Split
+	}
+	return total
+}
+
+func (t *RelayTeam) Hits() int {
+	hits := 0
+	for _, leg := range t.Legs {
+		hits += leg.Competitor.Hits()
+	}
+	return hits
+}
+
+func (t *RelayTeam) SpareRounds() int {
+	spares := 0
+	for _, leg := range t.Legs {
+		spares += leg.Competitor.SpareRounds()
+	}
+	return spares
+}
+
+func (t *RelayTeam) PenaltyLaps() (served, required int) {
+	for _, leg := range t.Legs {
+		s, r := leg.Competitor.PenaltyLaps()
+		served += s
+		required += r
+	}
+	return served, required
+}
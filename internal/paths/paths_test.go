@@ -0,0 +1,44 @@
+package paths
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestResolve(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Failed to get current dir: %v", err)
+	}
+
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"/tmp/events", "/tmp/events"},
+		{"events", filepath.Join(wd, "events")},
+		{"./configs/../configs/config.json", filepath.Join(wd, "configs", "config.json")},
+		{"~", home},
+		{"~/races/events", filepath.Join(home, "races", "events")},
+		{"~events", filepath.Join(wd, "~events")},
+	}
+
+	for _, tt := range tests {
+		got, err := Resolve(tt.path)
+		if err != nil {
+			t.Errorf("Resolve(%q) error = %v", tt.path, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("Resolve(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+
+	if _, err := Resolve(""); err == nil {
+		t.Error("Resolve() should return error for an empty path")
+	}
+}
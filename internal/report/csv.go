@@ -0,0 +1,128 @@
+package report
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"biathlon_events_parser/internal/models"
+	"biathlon_events_parser/internal/rules"
+)
+
+func WriteResultsCSV(w io.Writer, competitors map[int]*models.Competitor, cfg *models.Config, comma rune) error {
+	r := rules.For(cfg)
+	list, placeList := resultRows(competitors, cfg, r)
+
+	cw := csv.NewWriter(w)
+	cw.Comma = comma
+
+	header := []string{"place", "id", "bib", "name", "nation", "team", "status", "total_time"}
+	for i := 1; i <= cfg.Laps; i++ {
+		header = append(header, fmt.Sprintf("lap%d_time", i), fmt.Sprintf("lap%d_speed", i))
+	}
+	header = append(header, "penalty_time", "penalty_speed", "time_penalty", "hits", "shots", "misses", "comment")
+	cw.Write(header)
+
+	for i, comp := range list {
+		total := ""
+		if isFinisher(comp) && r.Legs() == 0 {
+			total = formatDuration(r.TotalTime(comp))
+		}
+
+		row := []string{
+			formatPlace(placeList[i]),
+			strconv.Itoa(comp.ID),
+			formatBib(comp.Bib),
+			comp.Name,
+			comp.Nation,
+			comp.Team,
+			strings.Trim(comp.Status, "[]"),
+			total,
+		}
+
+		for lap := 0; lap < cfg.Laps; lap++ {
+			if lap < len(comp.LapTimes) {
+				row = append(row, formatDuration(comp.LapTimes[lap]), formatSpeed(comp.LapSpeeds[lap]))
+			} else {
+				row = append(row, "", "")
+			}
+		}
+
+		penaltyTime, penaltySpeed := "", ""
+		if comp.PenaltyTime > 0 {
+			penaltyTime, penaltySpeed = formatDuration(comp.PenaltyTime), formatSpeed(comp.PenaltySpeed)
+		}
+
+		timePenalty := ""
+		if !r.PenaltyLoops() {
+			timePenalty = formatDuration(r.TimePenalty(comp))
+		}
+
+		row = append(row,
+			penaltyTime,
+			penaltySpeed,
+			timePenalty,
+			strconv.Itoa(comp.Hits()),
+			strconv.Itoa(models.ShotsPerVisit*cfg.FiringLines),
+			formatStages(comp),
+			comp.Comment,
+		)
+		cw.Write(row)
+	}
+
+	cw.Flush()
+	if err := cw.Error(); err != nil {
+		return fmt.Errorf("failed to write CSV report: %w", err)
+	}
+
+	return nil
+}
+
+func WriteSplitsCSV(w io.Writer, competitors map[int]*models.Competitor, cfg *models.Config, comma rune) error {
+	list, _ := resultRows(competitors, cfg, rules.For(cfg))
+
+	cw := csv.NewWriter(w)
+	cw.Comma = comma
+
+	cw.Write([]string{"id", "name", "status", "lap", "lap_time", "lap_speed"})
+
+	for _, comp := range list {
+		for i, t := range comp.LapTimes {
+			cw.Write([]string{
+				strconv.Itoa(comp.ID),
+				comp.Name,
+				strings.Trim(comp.Status, "[]"),
+				strconv.Itoa(i + 1),
+				formatDuration(t),
+				formatSpeed(comp.LapSpeeds[i]),
+			})
+		}
+	}
+
+	cw.Flush()
+	if err := cw.Error(); err != nil {
+		return fmt.Errorf("failed to write CSV splits: %w", err)
+	}
+
+	return nil
+}
+
+func formatPlace(place int) string {
+	if place == 0 {
+		return ""
+	}
+	return strconv.Itoa(place)
+}
+
+func formatBib(bib int) string {
+	if bib == 0 {
+		return ""
+	}
+	return strconv.Itoa(bib)
+}
+
+func formatSpeed(speed float64) string {
+	return strconv.FormatFloat(speed, 'f', 3, 64)
+}
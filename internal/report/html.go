@@ -0,0 +1,135 @@
+package report
+
+import (
+	_ "embed"
+	"fmt"
+	"html/template"
+	"io"
+	"math"
+	"strings"
+
+	"biathlon_events_parser/internal/models"
+	"biathlon_events_parser/internal/rules"
+)
+
+//go:embed templates/results.html
+var resultsTemplate string
+
+var resultsPage = template.Must(template.New("results").Parse(resultsTemplate))
+
+type htmlLap struct {
+	Number int
+	Time   string
+	Speed  string
+}
+
+type htmlVisit struct {
+	FiringRange int
+	Hits        int
+	Misses      int
+	Time        string
+	Penalty     string
+}
+
+type htmlRow struct {
+	Place       int
+	PlaceSort   int
+	ID          int
+	Bib         int
+	Name        string
+	Nation      string
+	Team        string
+	Status      string
+	StatusClass string
+	TotalTime   string
+	TotalSort   int64
+	Behind      string
+	BehindSort  int64
+	Hits        int
+	Shots       int
+	Stages      string
+	Penalty     string
+	PenaltySort int64
+	Laps        []htmlLap
+	Visits      []htmlVisit
+	Comment     string
+}
+
+func MakeHTMLReport(w io.Writer, competitors map[int]*models.Competitor, cfg *models.Config) error {
+	r := rules.For(cfg)
+	list, placeList := resultRows(competitors, cfg, r)
+	leader := leaderTime(list, r)
+
+	rows := make([]htmlRow, 0, len(list))
+	for i, comp := range list {
+		status := strings.Trim(comp.Status, "[]")
+		row := htmlRow{
+			Place:       placeList[i],
+			PlaceSort:   math.MaxInt32,
+			ID:          comp.ID,
+			Bib:         comp.Bib,
+			Name:        comp.Name,
+			Nation:      comp.Nation,
+			Team:        comp.Team,
+			Status:      status,
+			StatusClass: strings.ToLower(status),
+			TotalSort:   math.MaxInt64,
+			BehindSort:  math.MaxInt64,
+			Hits:        comp.Hits(),
+			Shots:       models.ShotsPerVisit * cfg.FiringLines,
+			Stages:      formatStages(comp),
+			Comment:     comp.Comment,
+		}
+
+		if isFinisher(comp) && r.Legs() == 0 {
+			total := r.TotalTime(comp)
+			row.PlaceSort = row.Place
+			row.TotalTime = formatDuration(total)
+			row.TotalSort = total.Milliseconds()
+			row.BehindSort = (total - leader).Milliseconds()
+			row.Behind = formatGap(total, leader)
+		}
+
+		if !r.PenaltyLoops() {
+			penalty := r.TimePenalty(comp)
+			row.Penalty = formatDuration(penalty)
+			row.PenaltySort = penalty.Milliseconds()
+		}
+
+		for j, t := range comp.LapTimes {
+			row.Laps = append(row.Laps, htmlLap{
+				Number: j + 1,
+				Time:   formatDuration(t),
+				Speed:  formatSpeed(comp.LapSpeeds[j]),
+			})
+		}
+
+		for _, v := range comp.RangeVisits {
+			visit := htmlVisit{
+				FiringRange: v.FiringRange,
+				Hits:        v.Hits(),
+				Misses:      v.Misses(),
+			}
+			if !v.Leave.IsZero() {
+				visit.Time = formatDuration(v.Leave.Sub(v.Enter))
+			}
+			if v.PenaltyTime() > 0 {
+				visit.Penalty = formatDuration(v.PenaltyTime())
+			}
+			row.Visits = append(row.Visits, visit)
+		}
+
+		rows = append(rows, row)
+	}
+
+	page := struct {
+		Rows        []htmlRow
+		TimePenalty bool
+	}{rows, !r.PenaltyLoops()}
+
+	if err := resultsPage.Execute(w, page); err != nil {
+		return fmt.Errorf("failed to write HTML report: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,104 @@
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+
+	"biathlon_events_parser/internal/models"
+	"biathlon_events_parser/internal/rules"
+)
+
+type jsonSplit struct {
+	Time  string  `json:"time"`
+	Speed float64 `json:"speed"`
+}
+
+type jsonShooting struct {
+	Hits        int   `json:"hits"`
+	Shots       int   `json:"shots"`
+	Misses      []int `json:"missesPerStage"`
+	SpareRounds int   `json:"spareRounds,omitempty"`
+}
+
+type jsonResult struct {
+	Place       int          `json:"place,omitempty"`
+	Status      string       `json:"status"`
+	ID          int          `json:"id"`
+	Bib         int          `json:"bib,omitempty"`
+	Name        string       `json:"name,omitempty"`
+	Nation      string       `json:"nation,omitempty"`
+	Team        string       `json:"team,omitempty"`
+	RelayTeam   int          `json:"relayTeam,omitempty"`
+	RelayLeg    int          `json:"relayLeg,omitempty"`
+	TotalTime   string       `json:"totalTime,omitempty"`
+	Laps        []jsonSplit  `json:"laps"`
+	Penalty     *jsonSplit   `json:"penalty,omitempty"`
+	TimePenalty string       `json:"timePenalty,omitempty"`
+	Shooting    jsonShooting `json:"shooting"`
+	Comment     string       `json:"comment,omitempty"`
+}
+
+func MakeJSONReport(w io.Writer, competitors map[int]*models.Competitor, cfg *models.Config) error {
+	r := rules.For(cfg)
+	list, placeList := resultRows(competitors, cfg, r)
+
+	results := make([]jsonResult, 0, len(list))
+	for i, comp := range list {
+		res := jsonResult{
+			Place:     placeList[i],
+			Status:    strings.Trim(comp.Status, "[]"),
+			ID:        comp.ID,
+			Bib:       comp.Bib,
+			Name:      comp.Name,
+			Nation:    comp.Nation,
+			Team:      comp.Team,
+			RelayTeam: comp.RelayTeam,
+			RelayLeg:  comp.RelayLeg,
+			Laps:      make([]jsonSplit, 0, len(comp.LapTimes)),
+			Shooting: jsonShooting{
+				Hits:        comp.Hits(),
+				Shots:       models.ShotsPerVisit * cfg.FiringLines,
+				Misses:      make([]int, 0, len(comp.RangeVisits)),
+				SpareRounds: comp.SpareRounds(),
+			},
+			Comment: comp.Comment,
+		}
+
+		if isFinisher(comp) && r.Legs() == 0 {
+			res.TotalTime = formatDuration(r.TotalTime(comp))
+		}
+
+		for j, t := range comp.LapTimes {
+			res.Laps = append(res.Laps, jsonSplit{Time: formatDuration(t), Speed: roundSpeed(comp.LapSpeeds[j])})
+		}
+
+		if !r.PenaltyLoops() {
+			res.TimePenalty = formatDuration(r.TimePenalty(comp))
+		}
+
+		if comp.PenaltyTime > 0 {
+			res.Penalty = &jsonSplit{Time: formatDuration(comp.PenaltyTime), Speed: roundSpeed(comp.PenaltySpeed)}
+		}
+
+		for _, v := range comp.RangeVisits {
+			res.Shooting.Misses = append(res.Shooting.Misses, v.Misses())
+		}
+
+		results = append(results, res)
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(results); err != nil {
+		return fmt.Errorf("failed to write JSON report: %w", err)
+	}
+
+	return nil
+}
+
+func roundSpeed(speed float64) float64 {
+	return math.Round(speed*1000) / 1000
+}
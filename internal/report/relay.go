@@ -0,0 +1,213 @@
+package report
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	"biathlon_events_parser/internal/models"
+	"biathlon_events_parser/internal/rules"
+)
+
+// RelayStandings builds the relay teams from the legs their competitors ran
+// and orders them like Standings orders competitors.
+func RelayStandings(competitors map[int]*models.Competitor, cfg *models.Config) []*models.RelayTeam {
+	r := rules.For(cfg)
+
+	byID := make(map[int]*models.RelayTeam)
+	for _, comp := range competitors {
+		if comp.RelayTeam == 0 {
+			continue
+		}
+		team, ok := byID[comp.RelayTeam]
+		if !ok {
+			team = &models.RelayTeam{ID: comp.RelayTeam}
+			byID[comp.RelayTeam] = team
+		}
+		team.Legs = append(team.Legs, models.RelayLeg{
+			Number:     comp.RelayLeg,
+			Competitor: comp,
+			Split:      r.TotalTime(comp),
+		})
+	}
+
+	list := make([]*models.RelayTeam, 0, len(byID))
+	for _, team := range byID {
+		sort.Slice(team.Legs, func(i, j int) bool {
+			return team.Legs[i].Number < team.Legs[j].Number
+		})
+		team.Name = team.Legs[0].Competitor.Team
+		team.Status = relayStatus(team, r)
+		list = append(list, team)
+	}
+
+	sort.Slice(list, func(i, j int) bool {
+		ti, tj := list[i], list[j]
+		finishedI := ti.Status == models.StatusFinished
+		finishedJ := tj.Status == models.StatusFinished
+
+		if finishedI && finishedJ && ti.TotalTime() != tj.TotalTime() {
+			return ti.TotalTime() < tj.TotalTime()
+		}
+		if finishedI != finishedJ {
+			return finishedI
+		}
+		if len(ti.Legs) != len(tj.Legs) {
+			return len(ti.Legs) > len(tj.Legs)
+		}
+		return ti.ID < tj.ID
+	})
+
+	return list
+}
+
+// relayUnassigned lists the competitors who never ran a leg, because no
+// team started them or handed over to them, ordered by ID.
+func relayUnassigned(competitors map[int]*models.Competitor) []*models.Competitor {
+	var list []*models.Competitor
+	for _, comp := range competitors {
+		if comp.RelayTeam == 0 {
+			list = append(list, comp)
+		}
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].ID < list[j].ID
+	})
+	return list
+}
+
+// relayRows lists the legs team by team in the order of RelayStandings,
+// followed by the competitors who never ran a leg.
+func relayRows(competitors map[int]*models.Competitor, cfg *models.Config) []*models.Competitor {
+	var list []*models.Competitor
+	for _, team := range RelayStandings(competitors, cfg) {
+		for _, leg := range team.Legs {
+			list = append(list, leg.Competitor)
+		}
+	}
+	return append(list, relayUnassigned(competitors)...)
+}
+
+func relayStatus(team *models.RelayTeam, r rules.Rules) string {
+	for _, leg := range team.Legs {
+		if leg.Competitor.Status == models.StatusDisqualified {
+			return models.StatusDisqualified
+		}
+	}
+
+	last := team.Legs[len(team.Legs)-1].Competitor
+	if len(team.Legs) == r.Legs() && last.Status == models.StatusFinished {
+		return models.StatusFinished
+	}
+	return models.StatusNotFinished
+}
+
+func relayPlaces(list []*models.RelayTeam) []int {
+	result := make([]int, len(list))
+	for i, team := range list {
+		if team.Status != models.StatusFinished {
+			continue
+		}
+		if i > 0 && result[i-1] != 0 && list[i-1].TotalTime() == team.TotalTime() {
+			result[i] = result[i-1]
+			continue
+		}
+		result[i] = i + 1
+	}
+	return result
+}
+
+func makeRelayReport(w io.Writer, competitors map[int]*models.Competitor, cfg *models.Config) error {
+	list := RelayStandings(competitors, cfg)
+	placeList := relayPlaces(list)
+	unassigned := relayUnassigned(competitors)
+
+	var leader time.Duration
+	if len(list) > 0 && list[0].Status == models.StatusFinished {
+		leader = list[0].TotalTime()
+	}
+
+	teamWidth, legWidth := 0, 0
+	for _, team := range list {
+		teamWidth = max(teamWidth, len([]rune(team.Name)))
+		for _, leg := range team.Legs {
+			legWidth = max(legWidth, len([]rune(leg.Competitor.DisplayName())))
+		}
+	}
+	for _, comp := range unassigned {
+		legWidth = max(legWidth, len([]rune(comp.DisplayName())))
+	}
+
+	targets := models.ShotsPerVisit * cfg.FiringLines
+	for i, team := range list {
+		total, gap := "", ""
+		if team.Status == models.StatusFinished {
+			total = formatDuration(team.TotalTime())
+			gap = formatGap(team.TotalTime(), leader)
+		}
+
+		id := fmt.Sprintf("%2d", team.ID)
+		if teamWidth > 0 {
+			id += fmt.Sprintf(" %-*s", teamWidth, team.Name)
+		}
+
+		line := fmt.Sprintf("%3s %-14s %s  %-12s %-13s %d/%d  spare rounds %d",
+			formatPlace(placeList[i]), team.Status, id, total, gap,
+			team.Hits(), targets*len(team.Legs), team.SpareRounds())
+		if served, required := team.PenaltyLaps(); team.Status == models.StatusFinished && served < required {
+			line += fmt.Sprintf("  penalty laps %d/%d", served, required)
+		}
+		if _, err := fmt.Fprintln(w, line); err != nil {
+			return fmt.Errorf("failed to write report: %w", err)
+		}
+
+		for _, leg := range team.Legs {
+			if err := writeRelayLeg(w, leg, cfg, legWidth); err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, comp := range unassigned {
+		id := fmt.Sprintf("%2d", comp.ID)
+		if legWidth > 0 {
+			id += fmt.Sprintf(" %-*s", legWidth, comp.DisplayName())
+		}
+		if _, err := fmt.Fprintf(w, "%3s %-14s %s  no relay leg\n", "", comp.Status, id); err != nil {
+			return fmt.Errorf("failed to write report: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func writeRelayLeg(w io.Writer, leg models.RelayLeg, cfg *models.Config, nameWidth int) error {
+	comp := leg.Competitor
+
+	split := comp.Status
+	if comp.Status == models.StatusFinished {
+		split = formatDuration(leg.Split)
+	}
+
+	id := fmt.Sprintf("%2d", comp.ID)
+	if nameWidth > 0 {
+		id += fmt.Sprintf(" %-*s", nameWidth, comp.DisplayName())
+	}
+
+	targets := fmt.Sprintf("%d/%d", comp.Hits(), models.ShotsPerVisit*cfg.FiringLines)
+	if stages := formatStages(comp); stages != "" {
+		targets += " (" + stages + ")"
+	}
+
+	line := fmt.Sprintf("    leg %d  %s  %-14s [%s]  %s  spare rounds %d",
+		leg.Number, id, split, formatLaps(comp, cfg), targets, comp.SpareRounds())
+	if len(comp.PenaltyTimes) > 0 {
+		line += fmt.Sprintf("  loops [%s]", formatDurations(comp.PenaltyTimes))
+	}
+
+	if _, err := fmt.Fprintln(w, line); err != nil {
+		return fmt.Errorf("failed to write report: %w", err)
+	}
+	return nil
+}
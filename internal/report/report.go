@@ -2,103 +2,112 @@ package report
 
 import (
 	"biathlon_events_parser/internal/models"
+	"biathlon_events_parser/internal/rules"
 	"fmt"
-	"sort"
+	"io"
+	"strconv"
 	"strings"
 	"time"
 )
 
-func MakeReport(competitors map[int]*models.Competitor, cfg *models.Config) error {
-	var list []*models.Competitor
-	for _, comp := range competitors {
-		updateCompetitorStatus(comp)
-		list = append(list, comp)
+func MakeReport(w io.Writer, competitors map[int]*models.Competitor, cfg *models.Config) error {
+	r := rules.For(cfg)
+	if r.Legs() > 0 {
+		return makeRelayReport(w, competitors, cfg)
 	}
 
-	sort.Slice(list, func(i, j int) bool {
-		ci, cj := list[i], list[j]
-		finishedI := ci.Status == "[Finished]" && !ci.ActualStart.IsZero()
-		finishedJ := cj.Status == "[Finished]" && !cj.ActualStart.IsZero()
-		notStartedI := ci.Status == "[NotStarted]"
-		notStartedJ := cj.Status == "[NotStarted]"
-
-		if finishedI && finishedJ {
-			totalI := calculateTotalTime(ci)
-			totalJ := calculateTotalTime(cj)
-			return totalI < totalJ
-		}
+	list := Standings(competitors, cfg)
+	placeList := places(list, r)
+	leader := leaderTime(list, r)
 
-		if finishedI != finishedJ {
-			return finishedI
-		}
+	nameWidth := 0
+	for _, comp := range list {
+		nameWidth = max(nameWidth, len([]rune(comp.DisplayName())))
+	}
+
+	for i, comp := range list {
+		lapsPart := formatLaps(comp, cfg)
 
-		if notStartedI != notStartedJ {
-			return !notStartedI
+		penaltyPart := "{,}"
+		switch {
+		case !r.PenaltyLoops():
+			penaltyPart = fmt.Sprintf("{+%s}", formatDuration(r.TimePenalty(comp)))
+		case comp.PenaltyTime > 0:
+			penaltyPart = fmt.Sprintf("{%s, %.3f}", formatDuration(comp.PenaltyTime), comp.PenaltySpeed)
 		}
 
-		return ci.ID < cj.ID
-	})
+		targets := fmt.Sprintf("%d/%d", comp.Hits(), models.ShotsPerVisit*cfg.FiringLines)
+		if stages := formatStages(comp); stages != "" {
+			targets += " (" + stages + ")"
+		}
 
-	for _, comp := range list {
-		lapsStrs := make([]string, 0, cfg.Laps)
-		for i := 0; i < cfg.Laps; i++ {
-			if i < len(comp.LapTimes) {
-				t := comp.LapTimes[i]
-				sp := comp.LapSpeeds[i]
-				lapsStrs = append(lapsStrs, fmt.Sprintf("{%s, %.3f}", formatDuration(t), sp))
-			} else {
-				lapsStrs = append(lapsStrs, "{,}")
-			}
+		total, gap := "", ""
+		if isFinisher(comp) {
+			totalTime := r.TotalTime(comp)
+			total = formatDuration(totalTime)
+			gap = formatGap(totalTime, leader)
 		}
-		lapsPart := strings.Join(lapsStrs, ", ")
 
-		penaltyPart := "{,}"
-		if comp.PenaltyTime > 0 {
-			penaltyPart = fmt.Sprintf("{%s, %.3f}", formatDuration(comp.PenaltyTime), comp.PenaltySpeed)
+		id := fmt.Sprintf("%2d", comp.ID)
+		if nameWidth > 0 {
+			id += fmt.Sprintf(" %-*s", nameWidth, comp.DisplayName())
 		}
 
-		targets := fmt.Sprintf("%d/%d", comp.Hits, comp.ShotsHit)
+		line := fmt.Sprintf("%3s %-14s %s  %-12s %-13s [%s] %s  %s",
+			formatPlace(placeList[i]), comp.Status, id, total, gap, lapsPart, penaltyPart, targets)
+		if len(comp.RangeTimes) > 0 {
+			line += fmt.Sprintf("  range [%s]", formatDurations(comp.RangeTimes))
+		}
+		if len(comp.PenaltyTimes) > 0 {
+			line += fmt.Sprintf("  loops [%s]", formatDurations(comp.PenaltyTimes))
+		}
+		if comp.CourseTime > 0 {
+			line += fmt.Sprintf("  course {%s, %.3f}", formatDuration(comp.CourseTime), comp.CourseSpeed)
+		}
+		if served, required := comp.PenaltyLaps(); r.PenaltyLoops() && comp.Status == models.StatusFinished && served < required {
+			line += fmt.Sprintf("  penalty laps %d/%d", served, required)
+		}
+		if !comp.DisqualifiedAt.IsZero() {
+			line += fmt.Sprintf("  disqualified at %s", comp.DisqualifiedAt.Format("15:04:05.000"))
+		}
 
-		fmt.Printf("%-12s %2d  [%s] %s  %s\n",
-			comp.Status, comp.ID, lapsPart, penaltyPart, targets)
+		if _, err := fmt.Fprintln(w, line); err != nil {
+			return fmt.Errorf("failed to write report: %w", err)
+		}
 	}
 
 	return nil
 }
 
-func updateCompetitorStatus(comp *models.Competitor) {
-	if comp.Status == "[NotFinished]" {
-		return
-	}
-
-	if !comp.ActualStart.IsZero() {
-		if comp.Status == "" {
-			comp.Status = "[Finished]"
+func formatLaps(c *models.Competitor, cfg *models.Config) string {
+	laps := make([]string, 0, cfg.Laps)
+	for i := 0; i < cfg.Laps; i++ {
+		switch {
+		case i < len(c.LapTimes):
+			laps = append(laps, fmt.Sprintf("{%s, %.3f}", formatDuration(c.LapTimes[i]), c.LapSpeeds[i]))
+		case i == len(c.LapTimes) && !c.ActualStart.IsZero():
+			laps = append(laps, "{DNF}")
+		default:
+			laps = append(laps, "{-}")
 		}
-	} else if !comp.ScheduledStart.IsZero() {
-		comp.Status = "[NotStarted]"
 	}
+	return strings.Join(laps, ", ")
 }
 
-func calculateTotalTime(c *models.Competitor) time.Duration {
-	total := lapTimesTotal(c) + c.PenaltyTime
-
-	if !c.ScheduledStart.IsZero() && !c.ActualStart.IsZero() {
-		startDiff := c.ActualStart.Sub(c.ScheduledStart)
-		if startDiff > 0 {
-			total += startDiff
-		}
+func formatStages(c *models.Competitor) string {
+	stages := make([]string, 0, len(c.RangeVisits))
+	for _, v := range c.RangeVisits {
+		stages = append(stages, strconv.Itoa(v.Misses()))
 	}
-
-	return total
+	return strings.Join(stages, "+")
 }
 
-func lapTimesTotal(c *models.Competitor) time.Duration {
-	var total time.Duration
-	for _, t := range c.LapTimes {
-		total += t
+func formatDurations(ds []time.Duration) string {
+	strs := make([]string, 0, len(ds))
+	for _, d := range ds {
+		strs = append(strs, formatDuration(d))
 	}
-	return total
+	return strings.Join(strs, ", ")
 }
 
 func formatDuration(d time.Duration) string {
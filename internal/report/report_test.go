@@ -2,41 +2,16 @@ package report
 
 import (
 	"biathlon_events_parser/internal/models"
+	"biathlon_events_parser/internal/rules"
 	"bytes"
+	"encoding/csv"
+	"encoding/json"
 	"fmt"
-	"io"
-	"os"
 	"strings"
 	"testing"
 	"time"
 )
 
-func TestLapTimesTotal(t *testing.T) {
-	comp := &models.Competitor{
-		LapTimes: []time.Duration{
-			30 * time.Second,
-			45 * time.Second,
-			60 * time.Second,
-		},
-	}
-
-	total := lapTimesTotal(comp)
-	expected := 135 * time.Second
-
-	if total != expected {
-		t.Errorf("lapTimesTotal() = %v, want %v", total, expected)
-	}
-
-	emptyComp := &models.Competitor{
-		LapTimes: []time.Duration{},
-	}
-
-	total = lapTimesTotal(emptyComp)
-	if total != 0 {
-		t.Errorf("lapTimesTotal() with empty array = %v, want 0", total)
-	}
-}
-
 func TestFormatDuration(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -75,148 +50,567 @@ func TestFormatDuration(t *testing.T) {
 	}
 }
 
-func TestUpdateCompetitorStatus(t *testing.T) {
-	tests := []struct {
-		name       string
-		competitor *models.Competitor
-		wantStatus string
-	}{
-		{
-			name: "Already NotFinished status",
-			competitor: &models.Competitor{
-				Status: "[NotFinished]",
+func TestMakeReport(t *testing.T) {
+	cfg := &models.Config{
+		Laps:        2,
+		LapLen:      1000,
+		PenaltyLen:  150,
+		FiringLines: 2,
+	}
+
+	competitors := map[int]*models.Competitor{
+		1: {
+			ID:          1,
+			ActualStart: time.Date(2023, 1, 1, 10, 0, 0, 0, time.UTC),
+			LapTimes:    []time.Duration{1 * time.Minute, 1*time.Minute + 10*time.Second},
+			LapSpeeds:   []float64{16.67, 15.38},
+			Status:      "[Finished]",
+			RangeVisits: []models.RangeVisit{
+				{Targets: []int{1, 2, 3, 4}},
+				{Targets: []int{1, 2, 3, 4, 5}},
 			},
-			wantStatus: "[NotFinished]",
 		},
-		{
-			name: "Has started but not finished",
-			competitor: &models.Competitor{
-				ActualStart: time.Now(),
-				Status:      "",
+		2: {
+			ID:           2,
+			ActualStart:  time.Date(2023, 1, 1, 10, 1, 0, 0, time.UTC),
+			LapTimes:     []time.Duration{55 * time.Second, 1 * time.Minute},
+			LapSpeeds:    []float64{18.18, 16.67},
+			PenaltyTime:  15 * time.Second,
+			PenaltySpeed: 10.0,
+			RangeTimes:   []time.Duration{20 * time.Second, 25 * time.Second},
+			PenaltyTimes: []time.Duration{15 * time.Second},
+			CourseTime:   75 * time.Second,
+			CourseSpeed:  26.667,
+			Status:       "[Finished]",
+			RangeVisits: []models.RangeVisit{
+				{Targets: []int{1, 2, 3}},
+				{Targets: []int{1, 2, 3, 4, 5}},
 			},
-			wantStatus: "[Finished]",
 		},
-		{
-			name: "Not started but scheduled",
-			competitor: &models.Competitor{
-				ScheduledStart: time.Now(),
-				ActualStart:    time.Time{}, 
-				Status:         "",
-			},
-			wantStatus: "[NotStarted]",
+		3: {
+			ID:          3,
+			ActualStart: time.Date(2023, 1, 1, 10, 2, 0, 0, time.UTC),
+			LapTimes:    []time.Duration{50 * time.Second},
+			LapSpeeds:   []float64{20.0},
+			Status:      "[NotFinished]",
+			Comment:     "Fell",
+			RangeVisits: []models.RangeVisit{{Targets: []int{1, 2}}},
+		},
+		4: {
+			ID:             4,
+			ScheduledStart: time.Date(2023, 1, 1, 10, 3, 0, 0, time.UTC),
+			DisqualifiedAt: time.Date(2023, 1, 1, 10, 4, 30, 0, time.UTC),
+			Status:         "[NotStarted]",
 		},
 	}
 
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			updateCompetitorStatus(tt.competitor)
-			if tt.competitor.Status != tt.wantStatus {
-				t.Errorf("updateCompetitorStatus() got status = %v, want %v",
-					tt.competitor.Status, tt.wantStatus)
-			}
-		})
+	var buf bytes.Buffer
+	err := MakeReport(&buf, competitors, cfg)
+	if err != nil {
+		t.Errorf("MakeReport() returned error: %v", err)
 	}
-}
+	output := buf.String()
 
-func TestCalculateTotalTime(t *testing.T) {
-	comp1 := &models.Competitor{
-		LapTimes: []time.Duration{
-			1 * time.Minute,
-			2 * time.Minute,
-		},
+	if !strings.Contains(output, "[Finished]") ||
+		!strings.Contains(output, "[NotFinished]") {
+		t.Errorf("MakeReport() output doesn't contain required status markers")
+	}
+
+	for _, want := range []string{"9/10 (1+0)", "8/10 (2+0)", "2/10 (3)", "0/10"} {
+		if !strings.Contains(output, want) {
+			t.Errorf("MakeReport() output doesn't contain shooting result %q", want)
+		}
+	}
+
+	if !strings.HasPrefix(output, "  1 [Finished]      2  01:55.000 ") {
+		t.Errorf("MakeReport() output doesn't start with the winner's place and total time")
+	}
+
+	if !strings.Contains(output, "  2 [Finished]      1  02:10.000    +00:15.000") {
+		t.Errorf("MakeReport() output doesn't contain the second place with its gap")
+	}
+
+	if !strings.Contains(output, "[{00:50.000, 20.000}, {DNF}]") {
+		t.Errorf("MakeReport() output doesn't mark the unfinished lap")
 	}
 
-	expected1 := 3 * time.Minute
-	if got := calculateTotalTime(comp1); got != expected1 {
-		t.Errorf("calculateTotalTime() = %v, want %v", got, expected1)
+	if !strings.Contains(output, "[{-}, {-}]") {
+		t.Errorf("MakeReport() output doesn't mark laps that were never started")
 	}
 
-	comp2 := &models.Competitor{
-		LapTimes:    []time.Duration{1 * time.Minute},
-		PenaltyTime: 30 * time.Second,
+	if !strings.Contains(output, "range [00:20.000, 00:25.000]  loops [00:15.000]  course {01:15.000, 26.667}") {
+		t.Errorf("MakeReport() output doesn't contain range, penalty loop and course times")
 	}
 
-	expected2 := 1*time.Minute + 30*time.Second
-	if got := calculateTotalTime(comp2); got != expected2 {
-		t.Errorf("calculateTotalTime() = %v, want %v", got, expected2)
+	if !strings.Contains(output, "penalty laps 0/1") {
+		t.Errorf("MakeReport() output doesn't flag missing penalty laps")
 	}
 
-	now := time.Now()
-	comp3 := &models.Competitor{
-		LapTimes:       []time.Duration{1 * time.Minute},
-		ScheduledStart: now,
-		ActualStart:    now.Add(20 * time.Second),
+	if !strings.Contains(output, "disqualified at 10:04:30.000") {
+		t.Errorf("MakeReport() output doesn't contain disqualification time")
 	}
 
-	expected3 := 1*time.Minute + 20*time.Second
-	if got := calculateTotalTime(comp3); got != expected3 {
-		t.Errorf("calculateTotalTime() = %v, want %v", got, expected3)
+	for _, id := range []int{1, 2, 3, 4} {
+		if !strings.Contains(output, fmt.Sprintf("%d", id)) {
+			t.Errorf("MakeReport() output doesn't contain competitor ID %d", id)
+		}
 	}
 }
 
-func TestMakeReport(t *testing.T) {
-	cfg := &models.Config{
-		Laps:       2,
-		LapLen:     1000,
-		PenaltyLen: 150,
+func TestMakeReportNames(t *testing.T) {
+	cfg := &models.Config{Laps: 1, LapLen: 1000, PenaltyLen: 150, FiringLines: 1}
+
+	competitors := map[int]*models.Competitor{
+		1: {
+			ID:          1,
+			Name:        "Anna Berg",
+			Nation:      "NOR",
+			ActualStart: time.Date(2023, 1, 1, 10, 0, 0, 0, time.UTC),
+			LapTimes:    []time.Duration{1 * time.Minute},
+			LapSpeeds:   []float64{16.67},
+			Status:      "[Finished]",
+		},
+		2: {
+			ID:     2,
+			Status: "[NotStarted]",
+		},
 	}
 
+	var buf bytes.Buffer
+	if err := MakeReport(&buf, competitors, cfg); err != nil {
+		t.Fatalf("MakeReport() error = %v", err)
+	}
+
+	lines := strings.Split(buf.String(), "\n")
+	if !strings.HasPrefix(lines[0], "  1 [Finished]      1 Anna Berg (NOR)  01:00.000 ") {
+		t.Errorf("MakeReport() first line = %q, want the roster name after the ID", lines[0])
+	}
+	if !strings.HasPrefix(lines[1], "    [NotStarted]    2                  ") {
+		t.Errorf("MakeReport() second line = %q, want a blank name padded to the same width", lines[1])
+	}
+}
+
+func TestMakeJSONReport(t *testing.T) {
+	cfg := &models.Config{Laps: 2, LapLen: 1000, PenaltyLen: 150, FiringLines: 2}
+
 	competitors := map[int]*models.Competitor{
 		1: {
 			ID:          1,
 			ActualStart: time.Date(2023, 1, 1, 10, 0, 0, 0, time.UTC),
 			LapTimes:    []time.Duration{1 * time.Minute, 1*time.Minute + 10*time.Second},
-			LapSpeeds:   []float64{16.67, 15.38},
+			LapSpeeds:   []float64{16.6666, 14.2857},
 			Status:      "[Finished]",
-			Hits:        4,
-			ShotsHit:    5,
+			RangeVisits: []models.RangeVisit{
+				{Targets: []int{1, 2, 3, 4}},
+				{Targets: []int{1, 2, 3, 4, 5}},
+			},
 		},
 		2: {
-			ID:           2,
-			ActualStart:  time.Date(2023, 1, 1, 10, 1, 0, 0, time.UTC),
-			LapTimes:     []time.Duration{55 * time.Second, 1 * time.Minute},
-			LapSpeeds:    []float64{18.18, 16.67},
-			PenaltyTime:  15 * time.Second,
-			PenaltySpeed: 10.0,
-			Status:       "[Finished]",
-			Hits:         3,
-			ShotsHit:     5,
-		},
-		3: {
-			ID:          3,
-			ActualStart: time.Date(2023, 1, 1, 10, 2, 0, 0, time.UTC),
+			ID:          2,
+			ActualStart: time.Date(2023, 1, 1, 10, 1, 0, 0, time.UTC),
 			LapTimes:    []time.Duration{50 * time.Second},
 			LapSpeeds:   []float64{20.0},
 			Status:      "[NotFinished]",
 			Comment:     "Fell",
-			Hits:        2,
-			ShotsHit:    5,
 		},
 	}
 
-	oldStdout := os.Stdout
-	r, w, _ := os.Pipe()
-	os.Stdout = w
+	var buf bytes.Buffer
+	if err := MakeJSONReport(&buf, competitors, cfg); err != nil {
+		t.Fatalf("MakeJSONReport() error = %v", err)
+	}
 
-	err := MakeReport(competitors, cfg)
-	if err != nil {
-		t.Errorf("MakeReport() returned error: %v", err)
+	var got []jsonResult
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("MakeJSONReport() produced invalid JSON: %v", err)
+	}
+
+	if len(got) != 2 {
+		t.Fatalf("MakeJSONReport() returned %d results, want 2", len(got))
+	}
+
+	first := got[0]
+	if first.ID != 1 || first.Place != 1 || first.Status != "Finished" || first.TotalTime != "02:10.000" {
+		t.Errorf("first result = %+v, want competitor 1 in place 1 with total 02:10.000", first)
+	}
+	if first.Laps[0].Speed != 16.667 {
+		t.Errorf("first lap speed = %v, want 16.667", first.Laps[0].Speed)
+	}
+	if first.Shooting.Hits != 9 || first.Shooting.Shots != 10 || len(first.Shooting.Misses) != 2 || first.Shooting.Misses[0] != 1 {
+		t.Errorf("first shooting = %+v, want 9/10 with misses [1 0]", first.Shooting)
+	}
+
+	second := got[1]
+	if second.Place != 0 || second.TotalTime != "" || second.Comment != "Fell" {
+		t.Errorf("second result = %+v, want no place, no total time and comment Fell", second)
+	}
+}
+
+func TestWriteResultsCSV(t *testing.T) {
+	cfg := &models.Config{Laps: 2, LapLen: 1000, PenaltyLen: 150, FiringLines: 2}
+
+	competitors := map[int]*models.Competitor{
+		1: {
+			ID:          1,
+			Name:        "Anna Berg",
+			Nation:      "NOR",
+			Team:        "Norway",
+			Bib:         7,
+			ActualStart: time.Date(2023, 1, 1, 10, 0, 0, 0, time.UTC),
+			LapTimes:    []time.Duration{1 * time.Minute, 1*time.Minute + 10*time.Second},
+			LapSpeeds:   []float64{16.6666, 14.2857},
+			Status:      "[Finished]",
+			RangeVisits: []models.RangeVisit{
+				{Targets: []int{1, 2, 3, 4}},
+				{Targets: []int{1, 2, 3, 4, 5}},
+			},
+		},
+		2: {
+			ID:          2,
+			ActualStart: time.Date(2023, 1, 1, 10, 1, 0, 0, time.UTC),
+			LapTimes:    []time.Duration{50 * time.Second},
+			LapSpeeds:   []float64{20.0},
+			Status:      "[NotFinished]",
+			Comment:     "Fell, twice",
+		},
 	}
 
-	w.Close()
-	os.Stdout = oldStdout
 	var buf bytes.Buffer
-	io.Copy(&buf, r)
+	if err := WriteResultsCSV(&buf, competitors, cfg, ','); err != nil {
+		t.Fatalf("WriteResultsCSV() error = %v", err)
+	}
+
+	want := "place,id,bib,name,nation,team,status,total_time,lap1_time,lap1_speed,lap2_time,lap2_speed,penalty_time,penalty_speed,time_penalty,hits,shots,misses,comment\n" +
+		"1,1,7,Anna Berg,NOR,Norway,Finished,02:10.000,01:00.000,16.667,01:10.000,14.286,,,,9,10,1+0,\n" +
+		",2,,,,,NotFinished,,00:50.000,20.000,,,,,,0,10,,\"Fell, twice\"\n"
+	if buf.String() != want {
+		t.Errorf("WriteResultsCSV() =\n%s\nwant\n%s", buf.String(), want)
+	}
+
+	buf.Reset()
+	if err := WriteSplitsCSV(&buf, competitors, cfg, '\t'); err != nil {
+		t.Fatalf("WriteSplitsCSV() error = %v", err)
+	}
+
+	want = "id\tname\tstatus\tlap\tlap_time\tlap_speed\n" +
+		"1\tAnna Berg\tFinished\t1\t01:00.000\t16.667\n" +
+		"1\tAnna Berg\tFinished\t2\t01:10.000\t14.286\n" +
+		"2\t\tNotFinished\t1\t00:50.000\t20.000\n"
+	if buf.String() != want {
+		t.Errorf("WriteSplitsCSV() =\n%s\nwant\n%s", buf.String(), want)
+	}
+}
+
+func TestMakeHTMLReport(t *testing.T) {
+	cfg := &models.Config{Laps: 1, LapLen: 1000, PenaltyLen: 150, FiringLines: 1}
+
+	competitors := map[int]*models.Competitor{
+		1: {
+			ID:          1,
+			ActualStart: time.Date(2023, 1, 1, 10, 0, 0, 0, time.UTC),
+			LapTimes:    []time.Duration{1 * time.Minute},
+			LapSpeeds:   []float64{16.667},
+			Status:      "[Finished]",
+			RangeVisits: []models.RangeVisit{{FiringRange: 1, Targets: []int{1, 2, 3, 4, 5}}},
+		},
+		2: {
+			ID:          2,
+			ActualStart: time.Date(2023, 1, 1, 10, 1, 0, 0, time.UTC),
+			LapTimes:    []time.Duration{1*time.Minute + 12345*time.Millisecond},
+			LapSpeeds:   []float64{13.825},
+			Status:      "[Finished]",
+		},
+		3: {
+			ID:      3,
+			Status:  "[NotFinished]",
+			Comment: "<fell>",
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := MakeHTMLReport(&buf, competitors, cfg); err != nil {
+		t.Fatalf("MakeHTMLReport() error = %v", err)
+	}
 	output := buf.String()
 
-	if !strings.Contains(output, "[Finished]") ||
-		!strings.Contains(output, "[NotFinished]") {
-		t.Errorf("MakeReport() output doesn't contain required status markers")
+	for _, want := range []string{
+		"status-finished",
+		"status-notfinished",
+		"&#43;00:12.345",
+		"<details>",
+		"&lt;fell&gt;",
+	} {
+		if !strings.Contains(output, want) {
+			t.Errorf("MakeHTMLReport() output doesn't contain %q", want)
+		}
 	}
 
-	for _, id := range []int{1, 2, 3} {
-		if !strings.Contains(output, fmt.Sprintf("%d", id)) {
-			t.Errorf("MakeReport() output doesn't contain competitor ID %d", id)
+	if strings.Contains(output, "http://") || strings.Contains(output, "https://") {
+		t.Error("MakeHTMLReport() output must not reference external assets")
+	}
+
+	if strings.Contains(output, "Time penalty") {
+		t.Error("MakeHTMLReport() output should only show time penalties for individual races")
+	}
+}
+
+func TestPlacesAndGaps(t *testing.T) {
+	finisher := func(id int, total time.Duration) *models.Competitor {
+		return &models.Competitor{
+			ID:          id,
+			Status:      "[Finished]",
+			ActualStart: time.Date(2023, 1, 1, 10, 0, 0, 0, time.UTC),
+			LapTimes:    []time.Duration{total},
+		}
+	}
+
+	competitors := map[int]*models.Competitor{
+		1: finisher(1, 2*time.Minute),
+		2: finisher(2, 2*time.Minute),
+		3: finisher(3, 2*time.Minute+12345*time.Millisecond),
+		4: {ID: 4, Status: "[NotFinished]", ActualStart: time.Date(2023, 1, 1, 10, 0, 0, 0, time.UTC)},
+	}
+
+	cfg := &models.Config{Laps: 1}
+	r := rules.For(cfg)
+	list := Standings(competitors, cfg)
+	got := places(list, r)
+	want := []int{1, 1, 3, 0}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("places()[%d] = %d, want %d (competitor %d)", i, got[i], want[i], list[i].ID)
+		}
+	}
+
+	leader := leaderTime(list, r)
+	if gap := formatGap(r.TotalTime(list[1]), leader); gap != "" {
+		t.Errorf("formatGap() for a tie = %q, want empty", gap)
+	}
+	if gap := formatGap(r.TotalTime(list[2]), leader); gap != "+00:12.345" {
+		t.Errorf("formatGap() = %q, want +00:12.345", gap)
+	}
+}
+
+func TestPhotoFinish(t *testing.T) {
+	start := time.Date(2023, 1, 1, 10, 0, 0, 0, time.UTC)
+	finisher := func(id, position int) *models.Competitor {
+		return &models.Competitor{
+			ID:             id,
+			Status:         "[Finished]",
+			ActualStart:    start,
+			LastLapEnd:     start.Add(2 * time.Minute),
+			LapTimes:       []time.Duration{2 * time.Minute},
+			FinishPosition: position,
+		}
+	}
+
+	competitors := map[int]*models.Competitor{
+		1: finisher(1, 3),
+		2: finisher(2, 1),
+		3: finisher(3, 2),
+		4: finisher(4, 0),
+	}
+
+	cfg := &models.Config{Format: models.FormatMassStart, Laps: 1, StartTime: start}
+	list := Standings(competitors, cfg)
+	got := places(list, rules.For(cfg))
+
+	wantIDs := []int{2, 3, 1, 4}
+	wantPlaces := []int{1, 2, 3, 3}
+	for i := range wantIDs {
+		if list[i].ID != wantIDs[i] || got[i] != wantPlaces[i] {
+			t.Errorf("standings[%d] = competitor %d placed %d, want competitor %d placed %d",
+				i, list[i].ID, got[i], wantIDs[i], wantPlaces[i])
 		}
 	}
 }
+
+func TestIndividualTimePenalty(t *testing.T) {
+	cfg := &models.Config{
+		Format:      models.FormatIndividual,
+		Laps:        1,
+		LapLen:      1000,
+		PenaltyLen:  150,
+		FiringLines: 1,
+		MissPenalty: 45 * time.Second,
+	}
+
+	competitors := map[int]*models.Competitor{
+		1: {
+			ID:          1,
+			ActualStart: time.Date(2023, 1, 1, 10, 0, 0, 0, time.UTC),
+			LapTimes:    []time.Duration{2 * time.Minute},
+			LapSpeeds:   []float64{8.333},
+			Status:      "[Finished]",
+			RangeVisits: []models.RangeVisit{{Targets: []int{1, 2, 3}}},
+		},
+		2: {
+			ID:          2,
+			ActualStart: time.Date(2023, 1, 1, 10, 0, 30, 0, time.UTC),
+			LapTimes:    []time.Duration{2*time.Minute + 20*time.Second},
+			LapSpeeds:   []float64{7.143},
+			Status:      "[Finished]",
+			RangeVisits: []models.RangeVisit{{Targets: []int{1, 2, 3, 4, 5}}},
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := MakeReport(&buf, competitors, cfg); err != nil {
+		t.Fatalf("MakeReport() error = %v", err)
+	}
+	lines := strings.Split(buf.String(), "\n")
+	if !strings.HasPrefix(lines[0], "  1 [Finished]      2  02:20.000 ") || !strings.Contains(lines[0], "{+00:00.000}") {
+		t.Errorf("MakeReport() first line = %q, want the clean shooter first with no time penalty", lines[0])
+	}
+	if !strings.Contains(lines[1], "03:30.000    +01:10.000") || !strings.Contains(lines[1], "{+01:30.000}") {
+		t.Errorf("MakeReport() second line = %q, want two misses of 45s added to the total", lines[1])
+	}
+
+	buf.Reset()
+	if err := WriteResultsCSV(&buf, competitors, cfg, ','); err != nil {
+		t.Fatalf("WriteResultsCSV() error = %v", err)
+	}
+	if !strings.Contains(buf.String(), "penalty_time,penalty_speed,time_penalty,hits") ||
+		!strings.Contains(buf.String(), ",,,01:30.000,3,5,2,") {
+		t.Errorf("WriteResultsCSV() = %q, want a time_penalty column", buf.String())
+	}
+
+	buf.Reset()
+	if err := MakeJSONReport(&buf, competitors, cfg); err != nil {
+		t.Fatalf("MakeJSONReport() error = %v", err)
+	}
+	if !strings.Contains(buf.String(), `"timePenalty": "01:30.000"`) {
+		t.Errorf("MakeJSONReport() = %q, want a timePenalty field", buf.String())
+	}
+
+	buf.Reset()
+	if err := MakeHTMLReport(&buf, competitors, cfg); err != nil {
+		t.Fatalf("MakeHTMLReport() error = %v", err)
+	}
+	if !strings.Contains(buf.String(), "<th data-type=\"number\">Time penalty</th>") ||
+		!strings.Contains(buf.String(), "<td data-sort=\"90000\">01:30.000</td>") {
+		t.Errorf("MakeHTMLReport() output doesn't contain the time penalty column")
+	}
+}
+
+func relayFixture() (map[int]*models.Competitor, *models.Config) {
+	start := time.Date(2023, 1, 1, 10, 0, 0, 0, time.UTC)
+	cfg := &models.Config{Format: models.FormatRelay, Laps: 1, FiringLines: 1, StartTime: start}
+
+	competitors := make(map[int]*models.Competitor)
+	leg := func(id, team, number int, from, to time.Duration, status string) {
+		competitors[id] = &models.Competitor{
+			ID:          id,
+			Team:        fmt.Sprintf("Team %d", team),
+			RelayTeam:   team,
+			RelayLeg:    number,
+			Status:      status,
+			ActualStart: start.Add(from),
+			LastLapEnd:  start.Add(to),
+			LapTimes:    []time.Duration{to - from},
+			LapSpeeds:   []float64{1},
+			RangeVisits: []models.RangeVisit{{Targets: []int{1, 2, 3, 4, 5}, SpareRounds: 1}},
+		}
+		if number < models.RelayLegs && status == models.StatusFinished {
+			competitors[id].HandOverAt = start.Add(to)
+		}
+	}
+	for i := 0; i < models.RelayLegs; i++ {
+		leg(1+i, 1, i+1, time.Duration(i)*5*time.Minute, time.Duration(i+1)*5*time.Minute, models.StatusFinished)
+		leg(11+i, 11, i+1, time.Duration(i)*4*time.Minute, time.Duration(i+1)*4*time.Minute, models.StatusFinished)
+	}
+	leg(21, 21, 1, 0, 6*time.Minute, models.StatusFinished)
+	leg(22, 21, 2, 6*time.Minute, 8*time.Minute, models.StatusNotFinished)
+	competitors[23] = &models.Competitor{ID: 23, Team: "Team 21", Status: models.StatusNotStarted}
+
+	return competitors, cfg
+}
+
+func TestRelayReport(t *testing.T) {
+	competitors, cfg := relayFixture()
+
+	teams := RelayStandings(competitors, cfg)
+	wantIDs := []int{11, 1, 21}
+	wantTotals := []time.Duration{16 * time.Minute, 20 * time.Minute, 0}
+	for i, team := range teams {
+		if team.ID != wantIDs[i] {
+			t.Errorf("teams[%d].ID = %d, want %d", i, team.ID, wantIDs[i])
+		}
+		if wantTotals[i] != 0 && team.TotalTime() != wantTotals[i] {
+			t.Errorf("team %d TotalTime() = %v, want %v", team.ID, team.TotalTime(), wantTotals[i])
+		}
+	}
+	if teams[2].Status != models.StatusNotFinished || teams[2].Name != "Team 21" {
+		t.Errorf("team 21 = %s %q, want [NotFinished] Team 21", teams[2].Status, teams[2].Name)
+	}
+
+	var buf bytes.Buffer
+	if err := MakeReport(&buf, competitors, cfg); err != nil {
+		t.Fatalf("MakeReport() error = %v", err)
+	}
+	lines := strings.Split(strings.TrimRight(buf.String(), "\n"), "\n")
+	if len(lines) != 3+2*models.RelayLegs+2+1 {
+		t.Fatalf("relay report has %d lines, want a line per team and leg:\n%s", len(lines), buf.String())
+	}
+	wantLines := map[int]string{
+		0:  "  1 [Finished]     11 Team 11  16:00.000                  20/20  spare rounds 4",
+		5:  "  2 [Finished]      1 Team 1   20:00.000    +04:00.000    20/20  spare rounds 4",
+		6:  "    leg 1   1  05:00.000      [{05:00.000, 1.000}]  5/5 (0)  spare rounds 1",
+		10: "    [NotFinished]  21 Team 21                             10/10  spare rounds 2",
+		12: "    leg 2  22  [NotFinished]  [{02:00.000, 1.000}]  5/5 (0)  spare rounds 1",
+		13: "    [NotStarted]   23  no relay leg",
+	}
+	for i, want := range wantLines {
+		if lines[i] != want {
+			t.Errorf("line %d = %q, want %q", i, lines[i], want)
+		}
+	}
+}
+
+func TestRelayTableReports(t *testing.T) {
+	competitors, cfg := relayFixture()
+
+	var buf bytes.Buffer
+	if err := MakeJSONReport(&buf, competitors, cfg); err != nil {
+		t.Fatalf("MakeJSONReport() error = %v", err)
+	}
+	var results []jsonResult
+	if err := json.Unmarshal(buf.Bytes(), &results); err != nil {
+		t.Fatalf("json.Unmarshal() error = %v", err)
+	}
+
+	wantIDs := []int{11, 12, 13, 14, 1, 2, 3, 4, 21, 22, 23}
+	if len(results) != len(wantIDs) {
+		t.Fatalf("JSON report has %d results, want %d", len(results), len(wantIDs))
+	}
+	for i, res := range results {
+		if res.ID != wantIDs[i] {
+			t.Errorf("results[%d].ID = %d, want %d", i, res.ID, wantIDs[i])
+		}
+		if res.Place != 0 || res.TotalTime != "" {
+			t.Errorf("Competitor %d place = %d, total = %q, want neither for a relay leg", res.ID, res.Place, res.TotalTime)
+		}
+	}
+
+	buf.Reset()
+	if err := WriteResultsCSV(&buf, competitors, cfg, ','); err != nil {
+		t.Fatalf("WriteResultsCSV() error = %v", err)
+	}
+	rows, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatalf("csv.ReadAll() error = %v", err)
+	}
+	for _, row := range rows[1:] {
+		if row[0] != "" || row[7] != "" {
+			t.Errorf("CSV row %v has place %q and total %q, want neither for a relay leg", row[:8], row[0], row[7])
+		}
+	}
+
+	buf.Reset()
+	if err := MakeHTMLReport(&buf, competitors, cfg); err != nil {
+		t.Fatalf("MakeHTMLReport() error = %v", err)
+	}
+	if n := strings.Count(buf.String(), `<td data-sort="2147483647"></td>`); n != len(wantIDs) {
+		t.Errorf("HTML report leaves %d places blank, want all %d", n, len(wantIDs))
+	}
+}
@@ -0,0 +1,104 @@
+package report
+
+import (
+	"sort"
+	"time"
+
+	"biathlon_events_parser/internal/models"
+	"biathlon_events_parser/internal/rules"
+)
+
+func Standings(competitors map[int]*models.Competitor, cfg *models.Config) []*models.Competitor {
+	r := rules.For(cfg)
+
+	list := make([]*models.Competitor, 0, len(competitors))
+	for _, comp := range competitors {
+		list = append(list, comp)
+	}
+
+	sort.Slice(list, func(i, j int) bool {
+		ci, cj := list[i], list[j]
+		finishedI := isFinisher(ci)
+		finishedJ := isFinisher(cj)
+		notStartedI := ci.Status == models.StatusNotStarted
+		notStartedJ := cj.Status == models.StatusNotStarted
+
+		if finishedI && finishedJ {
+			totalI := r.TotalTime(ci)
+			totalJ := r.TotalTime(cj)
+			if totalI != totalJ {
+				return totalI < totalJ
+			}
+			if photoFinish(ci, cj) {
+				return ci.FinishPosition < cj.FinishPosition
+			}
+		}
+
+		if finishedI != finishedJ {
+			return finishedI
+		}
+
+		if notStartedI != notStartedJ {
+			return !notStartedI
+		}
+
+		return ci.ID < cj.ID
+	})
+
+	return list
+}
+
+// resultRows orders the competitors for the table formats and places them.
+// A relay leg has no place of its own, so relay rows come team by team
+// without places.
+func resultRows(competitors map[int]*models.Competitor, cfg *models.Config, r rules.Rules) ([]*models.Competitor, []int) {
+	if r.Legs() > 0 {
+		list := relayRows(competitors, cfg)
+		return list, make([]int, len(list))
+	}
+	list := Standings(competitors, cfg)
+	return list, places(list, r)
+}
+
+func places(list []*models.Competitor, r rules.Rules) []int {
+	result := make([]int, len(list))
+	for i, comp := range list {
+		if !isFinisher(comp) {
+			continue
+		}
+		if i > 0 && result[i-1] != 0 && tied(list[i-1], comp, r) {
+			result[i] = result[i-1]
+			continue
+		}
+		result[i] = i + 1
+	}
+	return result
+}
+
+func leaderTime(list []*models.Competitor, r rules.Rules) time.Duration {
+	if len(list) == 0 || !isFinisher(list[0]) {
+		return 0
+	}
+	return r.TotalTime(list[0])
+}
+
+func formatGap(total, leader time.Duration) string {
+	if total <= leader {
+		return ""
+	}
+	return "+" + formatDuration(total-leader)
+}
+
+func tied(a, b *models.Competitor, r rules.Rules) bool {
+	return r.TotalTime(a) == r.TotalTime(b) && !photoFinish(a, b)
+}
+
+// photoFinish reports whether a photo finish separates two competitors who
+// crossed the line on the same time.
+func photoFinish(a, b *models.Competitor) bool {
+	return a.FinishPosition != 0 && b.FinishPosition != 0 && a.FinishPosition != b.FinishPosition
+}
+
+func isFinisher(c *models.Competitor) bool {
+	return c.Status == models.StatusFinished && !c.ActualStart.IsZero()
+}
@@ -0,0 +1,125 @@
+package roster
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"biathlon_events_parser/internal/models"
+	"biathlon_events_parser/internal/paths"
+)
+
+func LoadRoster(rosterPath string) (models.Roster, error) {
+	path, err := paths.Resolve(rosterPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open roster file: %w", err)
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open roster file: %w", err)
+	}
+	defer file.Close()
+
+	return DecodeRoster(file, filepath.Ext(path))
+}
+
+func LoadRosterFS(fsys fs.FS, name string) (models.Roster, error) {
+	file, err := fsys.Open(name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open roster file: %w", err)
+	}
+	defer file.Close()
+
+	return DecodeRoster(file, filepath.Ext(name))
+}
+
+func DecodeRoster(r io.Reader, format string) (models.Roster, error) {
+	switch strings.ToLower(strings.TrimPrefix(format, ".")) {
+	case "json":
+		return decodeJSON(r)
+	case "csv":
+		return decodeCSV(r)
+	default:
+		return nil, fmt.Errorf("unsupported roster format %q", format)
+	}
+}
+
+func decodeJSON(r io.Reader) (models.Roster, error) {
+	var athletes []models.Athlete
+	if err := json.NewDecoder(r).Decode(&athletes); err != nil {
+		return nil, fmt.Errorf("failed to decode roster: %w", err)
+	}
+	return build(athletes)
+}
+
+func decodeCSV(r io.Reader) (models.Roster, error) {
+	records, err := csv.NewReader(r).ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode roster: %w", err)
+	}
+	if len(records) == 0 {
+		return models.Roster{}, nil
+	}
+
+	columns := make(map[string]int)
+	for i, name := range records[0] {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	if _, ok := columns["id"]; !ok {
+		return nil, fmt.Errorf("roster header has no id column")
+	}
+
+	get := func(record []string, column string) string {
+		i, ok := columns[column]
+		if !ok || i >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[i])
+	}
+
+	athletes := make([]models.Athlete, 0, len(records)-1)
+	for n, record := range records[1:] {
+		line := n + 2
+
+		id, err := strconv.Atoi(get(record, "id"))
+		if err != nil {
+			return nil, fmt.Errorf("invalid competitor ID on roster line %d: %w", line, err)
+		}
+
+		a := models.Athlete{
+			ID:     id,
+			Name:   get(record, "name"),
+			Nation: get(record, "nation"),
+			Team:   get(record, "team"),
+		}
+
+		if bib := get(record, "bib"); bib != "" {
+			a.Bib, err = strconv.Atoi(bib)
+			if err != nil {
+				return nil, fmt.Errorf("invalid bib on roster line %d: %w", line, err)
+			}
+		}
+
+		athletes = append(athletes, a)
+	}
+
+	return build(athletes)
+}
+
+func build(athletes []models.Athlete) (models.Roster, error) {
+	roster := make(models.Roster, len(athletes))
+	for _, a := range athletes {
+		if _, exists := roster[a.ID]; exists {
+			return nil, fmt.Errorf("duplicate competitor ID %d in roster", a.ID)
+		}
+		roster[a.ID] = a
+	}
+	return roster, nil
+}
@@ -0,0 +1,104 @@
+package roster
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"biathlon_events_parser/internal/models"
+)
+
+func writeRoster(t *testing.T, name, content string) {
+	t.Helper()
+
+	tmpDir := t.TempDir()
+	origWd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Failed to get current dir: %v", err)
+	}
+	t.Cleanup(func() { os.Chdir(origWd) })
+	os.Chdir(tmpDir)
+
+	if err := os.WriteFile(filepath.Join(tmpDir, name), []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write roster file: %v", err)
+	}
+}
+
+func TestLoadRosterJSON(t *testing.T) {
+	writeRoster(t, "roster.json", `[
+		{"id": 1, "name": "Anna Berg", "nation": "NOR", "team": "Norway", "bib": 7},
+		{"id": 2, "name": "Lena Roth", "nation": "GER"}
+	]`)
+
+	roster, err := LoadRoster("roster.json")
+	if err != nil {
+		t.Fatalf("LoadRoster() error = %v", err)
+	}
+
+	want := models.Athlete{ID: 1, Name: "Anna Berg", Nation: "NOR", Team: "Norway", Bib: 7}
+	if roster[1] != want {
+		t.Errorf("roster[1] = %+v, want %+v", roster[1], want)
+	}
+	if roster[2].Name != "Lena Roth" || roster[2].Bib != 0 {
+		t.Errorf("roster[2] = %+v, want Lena Roth without bib", roster[2])
+	}
+}
+
+func TestLoadRosterCSV(t *testing.T) {
+	writeRoster(t, "roster.csv", "bib,id,name,nation\n"+
+		"7,1,Anna Berg,NOR\n"+
+		",2,Lena Roth,GER\n")
+
+	roster, err := LoadRoster("roster.csv")
+	if err != nil {
+		t.Fatalf("LoadRoster() error = %v", err)
+	}
+
+	if len(roster) != 2 {
+		t.Fatalf("len(roster) = %d, want 2", len(roster))
+	}
+
+	want := models.Athlete{ID: 1, Name: "Anna Berg", Nation: "NOR", Bib: 7}
+	if roster[1] != want {
+		t.Errorf("roster[1] = %+v, want %+v", roster[1], want)
+	}
+}
+
+func TestLoadRosterErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		content string
+	}{
+		{"duplicate ID", "roster.json", `[{"id": 1}, {"id": 1}]`},
+		{"missing id column", "roster.csv", "name,nation\nAnna Berg,NOR\n"},
+		{"invalid ID", "roster.csv", "id,name\none,Anna Berg\n"},
+		{"invalid bib", "roster.csv", "id,bib\n1,seven\n"},
+		{"unsupported format", "roster.txt", "1 Anna Berg"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			writeRoster(t, tt.file, tt.content)
+
+			if _, err := LoadRoster(tt.file); err == nil {
+				t.Errorf("LoadRoster() should return error for %s", tt.name)
+			}
+		})
+	}
+}
+
+func TestLoadRosterFS(t *testing.T) {
+	fsys := fstest.MapFS{
+		"data/roster.csv": {Data: []byte("id,name\n1,Anna Berg\n")},
+	}
+
+	roster, err := LoadRosterFS(fsys, "data/roster.csv")
+	if err != nil {
+		t.Fatalf("LoadRosterFS() error = %v", err)
+	}
+	if roster[1].Name != "Anna Berg" {
+		t.Errorf("roster[1].Name = %q, want Anna Berg", roster[1].Name)
+	}
+}
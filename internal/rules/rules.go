@@ -0,0 +1,207 @@
+package rules
+
+import (
+	"time"
+
+	"biathlon_events_parser/internal/models"
+)
+
+type Rules interface {
+	Format() models.RaceFormat
+	// Stages returns the laps and firing lines the format requires, or zeros
+	// when any combination with no more firing lines than laps is allowed.
+	Stages() (laps, firingLines int)
+	PenaltyLoops() bool
+	// SharedStart reports whether the whole field starts together on the
+	// race start, which allows photo finish decisions.
+	SharedStart() bool
+	// DrawOptional reports whether competitors may go to the start line
+	// without a draw, to start on the race start.
+	DrawOptional() bool
+	// Legs returns the number of legs a relay team runs, or zero for races
+	// of individual competitors.
+	Legs() int
+	// SpareRounds returns the rounds a competitor may load by hand on each
+	// firing range visit once the magazine is empty.
+	SpareRounds() int
+	TimePenalty(c *models.Competitor) time.Duration
+	TotalTime(c *models.Competitor) time.Duration
+}
+
+const (
+	DefaultMissPenalty = time.Minute
+
+	// RelayRounds is the number of rounds a relay competitor has for the
+	// five targets of a firing range visit.
+	RelayRounds = 8
+)
+
+func Lookup(cfg *models.Config) (Rules, bool) {
+	base := generic{cfg: cfg}
+	switch cfg.Format {
+	case "":
+		return base, true
+	case models.FormatSprint:
+		return sprint{base}, true
+	case models.FormatIndividual:
+		return individual{base}, true
+	case models.FormatPursuit:
+		return pursuit{base}, true
+	case models.FormatMassStart:
+		return massStart{pursuit{base}}, true
+	case models.FormatRelay:
+		return relay{base}, true
+	default:
+		return base, false
+	}
+}
+
+func For(cfg *models.Config) Rules {
+	r, _ := Lookup(cfg)
+	return r
+}
+
+// generic is the race this tool has always timed: interval starts, penalty
+// loops and no fixed number of laps or firing lines.
+type generic struct {
+	cfg *models.Config
+}
+
+func (generic) Format() models.RaceFormat { return "" }
+
+func (generic) Stages() (int, int) { return 0, 0 }
+
+func (generic) PenaltyLoops() bool { return true }
+
+func (generic) SharedStart() bool { return false }
+
+func (generic) DrawOptional() bool { return false }
+
+func (generic) Legs() int { return 0 }
+
+func (generic) SpareRounds() int { return 0 }
+
+func (generic) TimePenalty(*models.Competitor) time.Duration { return 0 }
+
+func (generic) TotalTime(c *models.Competitor) time.Duration {
+	total := LapTimesTotal(c)
+
+	if !c.ScheduledStart.IsZero() && !c.ActualStart.IsZero() {
+		startDiff := c.ActualStart.Sub(c.ScheduledStart)
+		if startDiff > 0 {
+			total += startDiff
+		}
+	}
+
+	return total
+}
+
+type sprint struct {
+	generic
+}
+
+func (sprint) Format() models.RaceFormat { return models.FormatSprint }
+
+func (sprint) Stages() (int, int) { return 3, 2 }
+
+type individual struct {
+	generic
+}
+
+func (individual) Format() models.RaceFormat { return models.FormatIndividual }
+
+func (individual) Stages() (int, int) { return 5, 4 }
+
+func (individual) PenaltyLoops() bool { return false }
+
+func (r individual) TimePenalty(c *models.Competitor) time.Duration {
+	penalty := r.cfg.MissPenalty
+	if penalty == 0 {
+		penalty = DefaultMissPenalty
+	}
+	return time.Duration(c.Misses()) * penalty
+}
+
+func (r individual) TotalTime(c *models.Competitor) time.Duration {
+	return r.generic.TotalTime(c) + r.TimePenalty(c)
+}
+
+// pursuit starts competitors at their gaps behind the leader, so the total
+// time runs from the race start and finishing order decides the ranking.
+type pursuit struct {
+	generic
+}
+
+func (pursuit) Format() models.RaceFormat { return models.FormatPursuit }
+
+func (pursuit) Stages() (int, int) { return 5, 4 }
+
+func (r pursuit) TotalTime(c *models.Competitor) time.Duration {
+	total := LapTimesTotal(c)
+
+	if !c.ActualStart.IsZero() {
+		startDiff := c.ActualStart.Sub(r.cfg.StartTime)
+		if startDiff > 0 {
+			total += startDiff
+		}
+	}
+
+	return total
+}
+
+type massStart struct {
+	pursuit
+}
+
+func (massStart) Format() models.RaceFormat { return models.FormatMassStart }
+
+func (massStart) SharedStart() bool { return true }
+
+func (massStart) DrawOptional() bool { return true }
+
+// TotalTime of a mass start runs from the start gun to the finish line, so a
+// competitor who left early gains nothing and the finish order is the ranking.
+func (r massStart) TotalTime(c *models.Competitor) time.Duration {
+	if len(c.LapTimes) == 0 || c.LastLapEnd.IsZero() {
+		return r.pursuit.TotalTime(c)
+	}
+	return c.LastLapEnd.Sub(r.cfg.StartTime)
+}
+
+// relay starts the first legs together on the race start and every later leg
+// on its hand-over, so a leg split runs to the next hand-over or the finish.
+type relay struct {
+	generic
+}
+
+func (relay) Format() models.RaceFormat { return models.FormatRelay }
+
+func (relay) Stages() (int, int) { return 3, 2 }
+
+func (relay) DrawOptional() bool { return true }
+
+func (relay) Legs() int { return models.RelayLegs }
+
+func (relay) SpareRounds() int { return RelayRounds - models.ShotsPerVisit }
+
+func (r relay) TotalTime(c *models.Competitor) time.Duration {
+	start, end := c.ActualStart, c.HandOverAt
+	if c.RelayLeg == 1 {
+		start = r.cfg.StartTime
+	}
+	if end.IsZero() {
+		end = c.LastLapEnd
+	}
+	if start.IsZero() || end.IsZero() {
+		return LapTimesTotal(c)
+	}
+	return end.Sub(start)
+}
+
+func LapTimesTotal(c *models.Competitor) time.Duration {
+	var total time.Duration
+	for _, t := range c.LapTimes {
+		total += t
+	}
+	return total
+}
@@ -0,0 +1,220 @@
+package rules
+
+import (
+	"testing"
+	"time"
+
+	"biathlon_events_parser/internal/models"
+)
+
+func TestLapTimesTotal(t *testing.T) {
+	comp := &models.Competitor{
+		LapTimes: []time.Duration{
+			30 * time.Second,
+			45 * time.Second,
+			60 * time.Second,
+		},
+	}
+
+	total := LapTimesTotal(comp)
+	expected := 135 * time.Second
+
+	if total != expected {
+		t.Errorf("LapTimesTotal() = %v, want %v", total, expected)
+	}
+
+	emptyComp := &models.Competitor{
+		LapTimes: []time.Duration{},
+	}
+
+	total = LapTimesTotal(emptyComp)
+	if total != 0 {
+		t.Errorf("LapTimesTotal() with empty array = %v, want 0", total)
+	}
+}
+
+func TestTotalTime(t *testing.T) {
+	r := For(&models.Config{})
+
+	comp1 := &models.Competitor{
+		LapTimes: []time.Duration{
+			1 * time.Minute,
+			2 * time.Minute,
+		},
+	}
+
+	expected1 := 3 * time.Minute
+	if got := r.TotalTime(comp1); got != expected1 {
+		t.Errorf("TotalTime() = %v, want %v", got, expected1)
+	}
+
+	comp2 := &models.Competitor{
+		LapTimes:    []time.Duration{1 * time.Minute},
+		PenaltyTime: 30 * time.Second,
+	}
+
+	expected2 := 1 * time.Minute
+	if got := r.TotalTime(comp2); got != expected2 {
+		t.Errorf("TotalTime() = %v, want %v", got, expected2)
+	}
+
+	now := time.Now()
+	comp3 := &models.Competitor{
+		LapTimes:       []time.Duration{1 * time.Minute},
+		ScheduledStart: now,
+		ActualStart:    now.Add(20 * time.Second),
+	}
+
+	expected3 := 1*time.Minute + 20*time.Second
+	if got := r.TotalTime(comp3); got != expected3 {
+		t.Errorf("TotalTime() = %v, want %v", got, expected3)
+	}
+}
+
+func TestTotalTimePenaltyLoop(t *testing.T) {
+	r := For(&models.Config{})
+
+	// The penalty loop is run inside the lap, so its time is already part
+	// of the lap time and must not be added again.
+	served := &models.Competitor{
+		LapTimes:    []time.Duration{2*time.Minute + 30*time.Second},
+		PenaltyTime: 30 * time.Second,
+	}
+	clean := &models.Competitor{
+		LapTimes: []time.Duration{2*time.Minute + 40*time.Second},
+	}
+
+	if got, want := r.TotalTime(served), 2*time.Minute+30*time.Second; got != want {
+		t.Errorf("TotalTime() with a served loop = %v, want %v", got, want)
+	}
+	if r.TotalTime(served) >= r.TotalTime(clean) {
+		t.Error("a competitor with a served loop and a faster lap should rank ahead")
+	}
+}
+
+func TestFormatTotalTime(t *testing.T) {
+	start := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+
+	comp := &models.Competitor{
+		ScheduledStart: start.Add(30 * time.Second),
+		ActualStart:    start.Add(40 * time.Second),
+		LapTimes:       []time.Duration{10 * time.Minute},
+		RangeVisits: []models.RangeVisit{
+			{Targets: []int{1, 2, 3}},
+		},
+	}
+
+	tests := []struct {
+		format models.RaceFormat
+		want   time.Duration
+	}{
+		{"", 10*time.Minute + 10*time.Second},
+		{models.FormatSprint, 10*time.Minute + 10*time.Second},
+		{models.FormatIndividual, 12*time.Minute + 10*time.Second},
+		{models.FormatPursuit, 10*time.Minute + 40*time.Second},
+		{models.FormatMassStart, 10*time.Minute + 40*time.Second},
+	}
+
+	for _, tt := range tests {
+		r, ok := Lookup(&models.Config{Format: tt.format, StartTime: start})
+		if !ok {
+			t.Fatalf("Lookup(%q) found no rules", tt.format)
+		}
+		if r.Format() != tt.format {
+			t.Errorf("Lookup(%q).Format() = %q", tt.format, r.Format())
+		}
+		if got := r.TotalTime(comp); got != tt.want {
+			t.Errorf("%q TotalTime() = %v, want %v", tt.format, got, tt.want)
+		}
+	}
+
+	if _, ok := Lookup(&models.Config{Format: "single-mixed-relay"}); ok {
+		t.Error("Lookup() should not know the single-mixed-relay format")
+	}
+}
+
+func TestMassStartTotalTime(t *testing.T) {
+	start := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	r := For(&models.Config{Format: models.FormatMassStart, StartTime: start})
+
+	comp := &models.Competitor{
+		ActualStart: start.Add(-2 * time.Second),
+		LastLapEnd:  start.Add(10 * time.Minute),
+		LapTimes:    []time.Duration{10*time.Minute + 2*time.Second},
+	}
+	if got, want := r.TotalTime(comp), 10*time.Minute; got != want {
+		t.Errorf("TotalTime() after a false start = %v, want %v", got, want)
+	}
+
+	comp.ActualStart = start.Add(5 * time.Second)
+	comp.LapTimes = []time.Duration{9*time.Minute + 55*time.Second}
+	if got, want := r.TotalTime(comp), 10*time.Minute; got != want {
+		t.Errorf("TotalTime() after a late start = %v, want %v", got, want)
+	}
+}
+
+func TestRelayTotalTime(t *testing.T) {
+	start := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	r := For(&models.Config{Format: models.FormatRelay, StartTime: start})
+
+	first := &models.Competitor{
+		RelayLeg:    1,
+		ActualStart: start.Add(time.Second),
+		LastLapEnd:  start.Add(20 * time.Minute),
+		HandOverAt:  start.Add(20*time.Minute + 2*time.Second),
+		LapTimes:    []time.Duration{20*time.Minute - time.Second},
+	}
+	if got, want := r.TotalTime(first), 20*time.Minute+2*time.Second; got != want {
+		t.Errorf("first leg TotalTime() = %v, want %v", got, want)
+	}
+
+	last := &models.Competitor{
+		RelayLeg:    4,
+		ActualStart: start.Add(time.Hour),
+		LastLapEnd:  start.Add(time.Hour + 19*time.Minute),
+		LapTimes:    []time.Duration{19 * time.Minute},
+	}
+	if got, want := r.TotalTime(last), 19*time.Minute; got != want {
+		t.Errorf("last leg TotalTime() = %v, want %v", got, want)
+	}
+
+	if got, want := r.SpareRounds(), 3; got != want {
+		t.Errorf("SpareRounds() = %d, want %d", got, want)
+	}
+	if got, want := r.Legs(), models.RelayLegs; got != want {
+		t.Errorf("Legs() = %d, want %d", got, want)
+	}
+}
+
+func TestStagesAndPenaltyLoops(t *testing.T) {
+	tests := []struct {
+		format       models.RaceFormat
+		laps, lines  int
+		penaltyLoops bool
+		sharedStart  bool
+		drawOptional bool
+	}{
+		{"", 0, 0, true, false, false},
+		{models.FormatSprint, 3, 2, true, false, false},
+		{models.FormatIndividual, 5, 4, false, false, false},
+		{models.FormatPursuit, 5, 4, true, false, false},
+		{models.FormatMassStart, 5, 4, true, true, true},
+		{models.FormatRelay, 3, 2, true, false, true},
+	}
+
+	for _, tt := range tests {
+		r := For(&models.Config{Format: tt.format})
+		if laps, lines := r.Stages(); laps != tt.laps || lines != tt.lines {
+			t.Errorf("%q Stages() = %d, %d, want %d, %d", tt.format, laps, lines, tt.laps, tt.lines)
+		}
+		if r.PenaltyLoops() != tt.penaltyLoops {
+			t.Errorf("%q PenaltyLoops() = %v, want %v", tt.format, r.PenaltyLoops(), tt.penaltyLoops)
+		}
+		if r.SharedStart() != tt.sharedStart {
+			t.Errorf("%q SharedStart() = %v, want %v", tt.format, r.SharedStart(), tt.sharedStart)
+		}
+		if r.DrawOptional() != tt.drawOptional {
+			t.Errorf("%q DrawOptional() = %v, want %v", tt.format, r.DrawOptional(), tt.drawOptional)
+		}
+	}
+}
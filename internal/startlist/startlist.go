@@ -0,0 +1,69 @@
+package startlist
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"time"
+
+	"biathlon_events_parser/internal/models"
+	"biathlon_events_parser/internal/report"
+	"biathlon_events_parser/internal/rules"
+)
+
+type Entry struct {
+	CompetitorID int
+	Gap          time.Duration
+	Start        time.Time
+}
+
+// Pursuit lists the top finishers of a race in finishing order. Each of them
+// starts at the given time plus their gap behind the winner. A top of zero
+// or less takes every finisher.
+func Pursuit(competitors map[int]*models.Competitor, cfg *models.Config, start time.Time, top int) []Entry {
+	r := rules.For(cfg)
+
+	var entries []Entry
+	var leader time.Duration
+	for _, comp := range report.Standings(competitors, cfg) {
+		if comp.Status != models.StatusFinished || comp.ActualStart.IsZero() {
+			break
+		}
+		if top > 0 && len(entries) == top {
+			break
+		}
+
+		total := r.TotalTime(comp)
+		if len(entries) == 0 {
+			leader = total
+		}
+
+		entries = append(entries, Entry{
+			CompetitorID: comp.ID,
+			Gap:          total - leader,
+			Start:        start.Add(total - leader),
+		})
+	}
+
+	return entries
+}
+
+// Write prints the start list as an events file: every competitor is
+// registered and then drawn to their start time at the announce time.
+func Write(w io.Writer, entries []Entry, announce time.Time) error {
+	bw := bufio.NewWriter(w)
+	stamp := announce.Format("15:04:05.000")
+
+	for _, e := range entries {
+		fmt.Fprintf(bw, "[%s] 1 %d\n", stamp, e.CompetitorID)
+	}
+	for _, e := range entries {
+		fmt.Fprintf(bw, "[%s] 2 %d %s\n", stamp, e.CompetitorID, e.Start.Format("15:04:05.000"))
+	}
+
+	if err := bw.Flush(); err != nil {
+		return fmt.Errorf("failed to write start list: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,73 @@
+package startlist
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"biathlon_events_parser/internal/models"
+)
+
+func TestPursuit(t *testing.T) {
+	cfg := &models.Config{Laps: 1}
+	raceStart := time.Date(0, 1, 1, 10, 0, 0, 0, time.UTC)
+
+	finisher := func(id int, total time.Duration) *models.Competitor {
+		return &models.Competitor{
+			ID:          id,
+			Status:      models.StatusFinished,
+			ActualStart: raceStart,
+			LapTimes:    []time.Duration{total},
+		}
+	}
+
+	competitors := map[int]*models.Competitor{
+		1: finisher(1, 25*time.Minute+7691*time.Millisecond),
+		2: finisher(2, 25*time.Minute),
+		3: finisher(3, 26*time.Minute),
+		4: {ID: 4, Status: models.StatusNotFinished, ActualStart: raceStart},
+		5: {ID: 5, Status: models.StatusNotStarted},
+	}
+
+	start := time.Date(0, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	entries := Pursuit(competitors, cfg, start, 0)
+	want := []Entry{
+		{CompetitorID: 2, Gap: 0, Start: start},
+		{CompetitorID: 1, Gap: 7691 * time.Millisecond, Start: start.Add(7691 * time.Millisecond)},
+		{CompetitorID: 3, Gap: time.Minute, Start: start.Add(time.Minute)},
+	}
+	if len(entries) != len(want) {
+		t.Fatalf("Pursuit() = %v, want %v", entries, want)
+	}
+	for i := range want {
+		if entries[i] != want[i] {
+			t.Errorf("Pursuit()[%d] = %+v, want %+v", i, entries[i], want[i])
+		}
+	}
+
+	if entries := Pursuit(competitors, cfg, start, 2); len(entries) != 2 || entries[1].CompetitorID != 1 {
+		t.Errorf("Pursuit() with top 2 = %v, want the first two finishers", entries)
+	}
+}
+
+func TestWrite(t *testing.T) {
+	start := time.Date(0, 1, 1, 12, 0, 0, 0, time.UTC)
+	entries := []Entry{
+		{CompetitorID: 2, Start: start},
+		{CompetitorID: 1, Gap: 7691 * time.Millisecond, Start: start.Add(7691 * time.Millisecond)},
+	}
+
+	var out strings.Builder
+	if err := Write(&out, entries, start.Add(-30*time.Minute)); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+
+	want := "[11:30:00.000] 1 2\n" +
+		"[11:30:00.000] 1 1\n" +
+		"[11:30:00.000] 2 2 12:00:00.000\n" +
+		"[11:30:00.000] 2 1 12:00:07.691\n"
+	if out.String() != want {
+		t.Errorf("Write() =\n%s\nwant\n%s", out.String(), want)
+	}
+}